@@ -0,0 +1,38 @@
+package main
+
+import (
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+	"github.com/NETWAYS/go-check/result"
+)
+
+func queryAirflowSensors(params akcp.Client, c *Config, overall *result.Overall, deviceType int) error {
+	sensorType, err := akcp.GetSensorTypeInt("airflow", deviceType)
+	if err != nil {
+		return err
+	}
+
+	return querySensorByType(params, c, overall, deviceType, uint64(sensorType))
+}
+
+// Airflow sensors only warn of a failing fan, a high airflow is no problem.
+// The status of the device also depends on its high thresholds, so the sensor is evaluated
+// against the low thresholds of the device or the thresholds given by the user instead.
+// An airflow of 0% is CRITICAL if asked for, even if the thresholds allow it.
+func applyAirflow(sensor *akcp.SensorDetails, stoppedCritical bool) {
+	if sensor.SensorType != sensorProbePlus.Airflow {
+		return
+	}
+
+	if sensor.Status == akcp.SensorError || sensor.Status == akcp.NoStatus {
+		return
+	}
+
+	if sensor.Warning.Present || sensor.Critical.Present {
+		sensor.Status = evaluateThresholds(sensor.Value, sensor.Warning, sensor.Critical)
+	}
+
+	if stoppedCritical && sensor.Value == 0 {
+		sensor.Status = akcp.LowCritical
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+	"github.com/NETWAYS/go-check"
+)
+
+func TestApplyAirflow(t *testing.T) {
+	// Only the low thresholds of the device are read
+	lowOnly := func(lower float64) akcp.MayThreshold {
+		return akcp.MayThreshold{Present: true, Val: check.Threshold{Lower: lower, Upper: check.PosInf}}
+	}
+
+	fan := func(value float64, status akcp.SensorStatus) akcp.SensorDetails {
+		return akcp.SensorDetails{
+			Name:       "Rack Fan",
+			SensorType: sensorProbePlus.Airflow,
+			Value:      value,
+			Status:     status,
+			Warning:    lowOnly(20),
+			Critical:   lowOnly(10),
+		}
+	}
+
+	testcases := map[string]struct {
+		sensor          akcp.SensorDetails
+		stoppedCritical bool
+		expected        akcp.SensorStatus
+	}{
+		"highAirflow": {
+			sensor:   fan(85, akcp.HighWarning),
+			expected: akcp.Normal,
+		},
+		"lowWarning": {
+			sensor:   fan(15, akcp.LowWarning),
+			expected: akcp.LowWarning,
+		},
+		"lowCritical": {
+			sensor:   fan(5, akcp.Normal),
+			expected: akcp.LowCritical,
+		},
+		"stoppedWithoutThresholds": {
+			sensor:   akcp.SensorDetails{Name: "Rack Fan", SensorType: sensorProbePlus.Airflow, Status: akcp.Normal},
+			expected: akcp.Normal,
+		},
+		"stoppedCritical": {
+			sensor:          akcp.SensorDetails{Name: "Rack Fan", SensorType: sensorProbePlus.Airflow, Status: akcp.Normal},
+			stoppedCritical: true,
+			expected:        akcp.LowCritical,
+		},
+		"runningWithStoppedCritical": {
+			sensor:          fan(50, akcp.Normal),
+			stoppedCritical: true,
+			expected:        akcp.Normal,
+		},
+		"sensorError": {
+			sensor:          akcp.SensorDetails{Name: "Rack Fan", SensorType: sensorProbePlus.Airflow, Status: akcp.SensorError},
+			stoppedCritical: true,
+			expected:        akcp.SensorError,
+		},
+		"noAirflow": {
+			sensor:          akcp.SensorDetails{Name: "Temperature", SensorType: sensorProbePlus.Temperature, Status: akcp.HighWarning},
+			stoppedCritical: true,
+			expected:        akcp.HighWarning,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			sensor := tc.sensor
+			applyAirflow(&sensor, tc.stoppedCritical)
+
+			if sensor.Status != tc.expected {
+				t.Error("\nActual: ", sensor.Status, "\nExpected: ", tc.expected)
+			}
+		})
+	}
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
 	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/capture"
 	"github.com/NETWAYS/go-check"
 	"github.com/NETWAYS/go-check/perfdata"
 	"github.com/NETWAYS/go-check/result"
@@ -16,21 +20,61 @@ import (
 )
 
 type Config struct {
+	hostsParam               []string
+	hostsFile                string
+	hosts                    []string
 	hostname                 string
 	snmpVersionParam         string
 	snmpVersion              gosnmp.SnmpVersion
 	community                string
 	port                     uint16
+	transport                string
+	sourceAddress            string
+	timeout                  time.Duration
+	snmpTimeoutParam         time.Duration
+	snmpTimeout              time.Duration
+	retriesParam             int
+	retries                  int
 	mode                     string
 	device                   string
 	deviceType               int
 	sensorPort               string
 	excludeSensorType        []string
 	excludeSensorTypeInteger []uint32
-	// authProtocol		string
-	// authPassword 	string
-	// privProtocol		string
-	// privPassword 	string
+	includeNameParam         []string
+	excludeNameParam         []string
+	includePortParam         []string
+	excludePortParam         []string
+	filter                   sensorFilter
+	outputFormat             string
+	fromWalk                 string
+	record                   string
+	listen                   string
+	parallel                 int
+	report                   checkReport
+	requests                 *akcp.CountingClient
+	warningParam             []string
+	criticalParam            []string
+	warning                  thresholdRules
+	critical                 thresholdRules
+	contactStateParam        []string
+	contactStates            contactRules
+	currentScaleParam        []string
+	currentScales            currentScaleRules
+	airflowStoppedCritical   bool
+	timedOut                 bool
+	verbose                  bool
+	// SNMPv3
+	username           string
+	securityLevelParam string
+	securityLevel      gosnmp.SnmpV3MsgFlags
+	authProtocolParam  string
+	authProtocol       gosnmp.SnmpV3AuthProtocol
+	authPassword       string
+	privProtocolParam  string
+	privProtocol       gosnmp.SnmpV3PrivProtocol
+	privPassword       string
+	contextName        string
 }
 
 // Modes
@@ -40,6 +84,7 @@ const (
 	single
 	temperaturSensors
 	humiditySensors
+	serve
 	airflowSensors
 	drycontactSensors
 	current4to20mA
@@ -55,31 +100,47 @@ const (
 )
 
 var modes = map[string]uint64{
-	"queryAllSensors":    queryAllSensors,
-	"single":             single,
-	"temperatureSensors": temperaturSensors,
-	"humiditySensors":    humiditySensors,
-	"run_test_success":   runTestSuccess,
+	"queryAllSensors":     queryAllSensors,
+	"listPossibleSensors": listPossibleSensors,
+	"single":              single,
+	"temperatureSensors":  temperaturSensors,
+	"humiditySensors":     humiditySensors,
+	"airflowSensors":      airflowSensors,
+	"serve":               serve,
+	"run_test_success":    runTestSuccess,
 }
 
 func (c *Config) BindArguments(fs *pflag.FlagSet) {
-	fs.StringVarP(&c.hostname, "host", "h", "", "Hostname or IP of the targeted device (required)")
-	fs.StringVarP(&c.snmpVersionParam, "snmp_version", "", "2c", "Version of SNMP to use (1|2c)")
+	fs.StringArrayVarP(&c.hostsParam, "host", "h", nil, `Hostname or IP of the targeted device (required)
+	Can be used multiple times to check several devices at once`)
+	fs.StringVarP(&c.hostsFile, "hosts-file", "", "", "File with further devices to check, one hostname or IP per line")
+	fs.StringVarP(&c.snmpVersionParam, "snmp_version", "", "2c", "Version of SNMP to use (1|2c|3)")
 	fs.StringVarP(&c.community, "community", "c", "public", "SNMP Community string")
 	fs.Uint16VarP(&c.port, "port", "p", 161, "SNMP Port")
-	fs.StringVarP(&c.device, "device", "", "sensorProbe+", `Device type, may be one of:
-	- sensorProbe
-	- securityProbe
+	fs.StringVarP(&c.transport, "transport", "", "udp", "Transport protocol of SNMP (udp|tcp)")
+	fs.StringVarP(&c.sourceAddress, "source-address", "", "", "Local IP address to send the SNMP requests from")
+	fs.DurationVarP(&c.snmpTimeoutParam, "snmp-timeout", "", 0, `Timeout of a single SNMP request (e.g. 5s)
+	Derived from --timeout if not given, so that a device which stops answering leaves time for a partial result`)
+	fs.IntVarP(&c.retriesParam, "retries", "", -1, "Number of retries of an SNMP request, -1 derives them from --timeout")
+	fs.StringVarP(&c.device, "device", "", "auto", `Device type, may be one of:
+	- auto (detect the device type from the probe)
+	- sensorProbe (sensorProbe2, sensorProbe8)
+	- securityProbe (securityProbe 5E, 5ES)
 	- sensorProbe+
 	`)
 	fs.StringVarP(&c.mode, "mode", "m", "queryAllSensors", `Usage mode (default: queryAllSensors)
 	Possible modes:
 	- queryAllSensors: Query all the sensors and show their value and state
+	- listPossibleSensors: List all sensors of the device with their index, type and thresholds
 	- single: Query a single sensor (sensorPort must be set)
 	- temperatureSensors: Query all the temperature sensors
 	- humiditySensors: Query all the humidity sensors
+	- airflowSensors: Query all the airflow sensors, only a low airflow is a problem
+	- serve: Run as Prometheus exporter, serving the sensors of all devices on /metrics (see --listen)
 
-	The following modes will query the respective sensor types:
+	The following modes will query the respective sensor types on the sensorProbe+
+	(on the sensorProbe: temperature, humidity and dry_contact,
+	on the securityProbe additionally: security and siren):
 	- temperature
 	- humidity_dual
 	- temperature_dual
@@ -139,46 +200,138 @@ func (c *Config) BindArguments(fs *pflag.FlagSet) {
 	- door
 	- reader
 	`)
-	fs.StringVarP(&c.sensorPort, "sensorPort", "", "", "Sensor Port (required for single mode)")
+	fs.StringVarP(&c.sensorPort, "sensorPort", "", "", `Sensor Port (required for single mode)
+	May be given as "port", "port.subport", as the complete sensor index
+	(e.g. "1.2.1.1") or as the exact name of the sensor.
+	On the sensorProbe and securityProbe the index is "<table>.<port>" with the ports counted
+	from 0 as in the MIB (Port 1 is "0", its humidity sensor "17.0"), a bare port selects the first sensor at it`)
 	fs.StringArrayVarP(&c.excludeSensorType, "exclude", "e", nil, "Exclude specific sensor type, valid types are the same as are available for querying above, can be used multiple times")
+	fs.StringArrayVarP(&c.includeNameParam, "include-name", "", nil, "Only check sensors with a name matching this regular expression, can be used multiple times")
+	fs.StringArrayVarP(&c.excludeNameParam, "exclude-name", "", nil, "Do not check sensors with a name matching this regular expression, can be used multiple times")
+	fs.StringArrayVarP(&c.includePortParam, "include-port", "", nil, `Only check sensors at this port, can be used multiple times
+	May be given as "port", "port.subport", as the complete sensor index or as a range of ports ("1-4").
+	The ports of the sensorProbe and securityProbe are counted from 0 ("0-3" for the Ports 1 to 4)`)
+	fs.StringArrayVarP(&c.excludePortParam, "exclude-port", "", nil, "Do not check sensors at this port, same format as --include-port, can be used multiple times")
+
+	fs.StringArrayVarP(&c.warningParam, "warning", "w", nil, `Warning threshold (Nagios range), evaluated by the plugin instead of the device thresholds
+	May be limited to a sensor type ("temperature=18:27"), to a port as for --include-port ("3=18:27")
+	or to sensor names matching a regular expression ("/^Rack/=15:30"), can be used multiple times.
+	A selector starting with a digit is always a port, use "/^3$/=..." for a sensor named "3"`)
+	fs.StringArrayVarP(&c.criticalParam, "critical", "", nil, "Critical threshold, same format as --warning")
+	fs.StringArrayVarP(&c.contactStateParam, "contact-state", "", nil, `Expected state of dry contacts (open|closed), replaces the normal state configured on the device
+	A contact in the other state is CRITICAL, or WARNING with ":warning" ("closed:warning").
+	May be limited to a sensor type, a port ("3=open") or to sensor names matching a regular expression
+	("/^Door/=closed") as --warning, can be used multiple times`)
+	fs.StringArrayVarP(&c.currentScaleParam, "current-scale", "", nil, `Engineering values of 4 mA and 20 mA of current loop sensors, with an optional unit ("0:500:Pa")
+	Replaces the scaling configured on the device, the thresholds of the device are converted.
+	May be limited to a sensor type, a port or to sensor names as --warning, can be used multiple times`)
+	fs.BoolVarP(&c.airflowStoppedCritical, "airflow-stopped-critical", "", false, "An airflow of 0% is CRITICAL (the fan has stopped), even if the thresholds allow it")
+	fs.StringVarP(&c.fromWalk, "from-walk", "", "", `Read the values from a capture instead of querying a device (for testing and debugging)
+	The capture may be the output of "snmpwalk -On" or a JSON capture`)
+	fs.StringVarP(&c.record, "record", "", "", `Write all values fetched from the device and a walk of its AKCP subtree to this file
+	The capture contains no credentials and can be replayed with --from-walk (e.g. for bug reports)`)
+	fs.IntVarP(&c.parallel, "parallel", "", 1, `Number of SNMP sessions used to fetch independent tables concurrently
+	Increases the load on the device, 1 fetches one table after another`)
+	fs.StringVarP(&c.listen, "listen", "", ":9650", `Address of the HTTP server in serve mode
+	A different device than --host can be scraped with the target parameter (/metrics?target=192.168.1.2)`)
+	fs.StringVarP(&c.outputFormat, "output", "o", "text", `Output format (text|json)
+	json prints the device, all sensors with their state and the overall state, the exit code is kept`)
+
+	fs.StringVarP(&c.username, "username", "u", "", "SNMPv3 security name (required for SNMPv3)")
+	fs.StringVarP(&c.securityLevelParam, "security-level", "l", "authPriv", "SNMPv3 security level (noAuthNoPriv|authNoPriv|authPriv)")
+	fs.StringVarP(&c.authProtocolParam, "auth-protocol", "a", "SHA", "SNMPv3 authentication protocol (MD5|SHA|SHA-224|SHA-256|SHA-384|SHA-512)")
+	fs.StringVarP(&c.authPassword, "auth-passphrase", "A", "", "SNMPv3 authentication passphrase")
+	fs.StringVarP(&c.privProtocolParam, "priv-protocol", "x", "AES", "SNMPv3 privacy protocol (DES|AES|AES-192|AES-256|AES-192C|AES-256C)")
+	fs.StringVarP(&c.privPassword, "priv-passphrase", "X", "", "SNMPv3 privacy passphrase")
+	fs.StringVarP(&c.contextName, "context", "n", "", "SNMPv3 context name")
 }
 
-func (c *Config) Validate() error {
+func (c *Config) Validate() (err error) {
 	val, ok := modes[c.mode]
-	if !ok { // nolint: gocritic,nestif
-		if c.device == "sensorProbePlus" {
-			_, ok = sensorProbePlus.SensorsTypes[c.mode]
-			if !ok {
-				return errors.New("mode is not a valid value")
-			}
-		}
-	} else if val == runTestSuccess {
-		check.ExitRaw(0, "It seems like you can execute this programm")
-	} else if val == single && c.sensorPort == "" {
+	if ok && val == runTestSuccess {
+		return nil
+	} else if ok && val == single && c.sensorPort == "" {
 		return errors.New("no sensorPort was given")
 	}
 
+	c.warning, err = parseThresholdRules(c.warningParam)
+	if err != nil {
+		return err
+	}
+
+	c.critical, err = parseThresholdRules(c.criticalParam)
+	if err != nil {
+		return err
+	}
+
+	c.contactStates, err = parseContactRules(c.contactStateParam)
+	if err != nil {
+		return err
+	}
+
+	c.currentScales, err = parseCurrentScaleRules(c.currentScaleParam)
+	if err != nil {
+		return err
+	}
+
+	c.filter, err = parseSensorFilter(c.includeNameParam, c.excludeNameParam, c.includePortParam, c.excludePortParam)
+	if err != nil {
+		return err
+	}
+
+	err = c.validateHosts()
+	if err != nil {
+		return err
+	}
+
+	if c.parallel < 1 {
+		return errors.New("parallel must be at least 1")
+	}
+
+	err = c.resolveSNMPTimeout()
+	if err != nil {
+		return err
+	}
+
+	if c.transport != "udp" && c.transport != "tcp" {
+		return errors.New("invalid transport, must be udp or tcp")
+	}
+
+	if c.outputFormat != "text" && c.outputFormat != "json" {
+		return errors.New("invalid output format")
+	}
+
 	switch c.snmpVersionParam {
 	case "1":
 		c.snmpVersion = gosnmp.Version1
 	case "2c":
 		c.snmpVersion = gosnmp.Version2c
 	case "3":
-		return errors.New("SNMP v3 not yet implemented")
+		c.snmpVersion = gosnmp.Version3
+
+		err := c.validateSNMPv3()
+		if err != nil {
+			return err
+		}
 	default:
 		return errors.New("invalid SNMP version string")
 	}
 
 	switch c.device {
+	case "auto":
+		{
+			// The device is detected in Run, the sensor types can not be checked before that
+			c.deviceType = akcp.AutoDetectType
+
+			return nil
+		}
 	case "sensorProbe":
 		{
-			// TODO
-			return errors.New("not yet implemented")
+			c.deviceType = akcp.SensorProbeType
 		}
 	case "securityProbe":
 		{
-			// TODO
-			return errors.New("not yet implemented")
+			c.deviceType = akcp.SecurityProbeType
 		}
 	case "sensorProbe+":
 		{
@@ -190,7 +343,43 @@ func (c *Config) Validate() error {
 		}
 	}
 
-	if c.excludeSensorType == nil {
+	return c.resolveSensorTypes()
+}
+
+// Translates the mode, the excluded sensor types and the selectors of the rules into the sensor types of the device
+func (c *Config) resolveSensorTypes() (err error) {
+	c.warning, err = resolveSensorTypes(c.warning, c.deviceType)
+	if err != nil {
+		return fmt.Errorf("invalid threshold: %w", err)
+	}
+
+	c.critical, err = resolveSensorTypes(c.critical, c.deviceType)
+	if err != nil {
+		return fmt.Errorf("invalid threshold: %w", err)
+	}
+
+	c.contactStates, err = resolveSensorTypes(c.contactStates, c.deviceType)
+	if err != nil {
+		return fmt.Errorf("invalid contact state: %w", err)
+	}
+
+	c.currentScales, err = resolveSensorTypes(c.currentScales, c.deviceType)
+	if err != nil {
+		return fmt.Errorf("invalid current scale: %w", err)
+	}
+
+	if _, ok := modes[c.mode]; !ok {
+		// not one of the main modes, has to be a sensor type of the device
+		_, err := akcp.GetSensorTypeInt(c.mode, c.deviceType)
+		if err != nil {
+			return errors.New("mode is not a valid value")
+		}
+	}
+
+	// The default exclusions do not have to exist on every device
+	defaultExclusion := c.excludeSensorType == nil
+
+	if defaultExclusion {
 		c.excludeSensorType = append(c.excludeSensorType, "buzzer")
 	}
 
@@ -203,6 +392,10 @@ func (c *Config) Validate() error {
 			val, err := akcp.GetSensorTypeInt(tmp, c.deviceType)
 
 			if err != nil {
+				if defaultExclusion {
+					continue
+				}
+
 				return err
 			}
 
@@ -213,102 +406,418 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+func (c *Config) validateSNMPv3() error {
+	if c.username == "" {
+		return errors.New("no username was given, SNMPv3 requires a security name")
+	}
+
+	switch strings.ToLower(c.securityLevelParam) {
+	case "noauthnopriv":
+		c.securityLevel = gosnmp.NoAuthNoPriv
+	case "authnopriv":
+		c.securityLevel = gosnmp.AuthNoPriv
+	case "authpriv":
+		c.securityLevel = gosnmp.AuthPriv
+	default:
+		return errors.New("invalid SNMPv3 security level")
+	}
+
+	if c.securityLevel == gosnmp.NoAuthNoPriv {
+		c.authProtocol = gosnmp.NoAuth
+		c.privProtocol = gosnmp.NoPriv
+
+		return nil
+	}
+
+	switch strings.ToUpper(c.authProtocolParam) {
+	case "MD5":
+		c.authProtocol = gosnmp.MD5
+	case "SHA", "SHA1", "SHA-1":
+		c.authProtocol = gosnmp.SHA
+	case "SHA224", "SHA-224":
+		c.authProtocol = gosnmp.SHA224
+	case "SHA256", "SHA-256":
+		c.authProtocol = gosnmp.SHA256
+	case "SHA384", "SHA-384":
+		c.authProtocol = gosnmp.SHA384
+	case "SHA512", "SHA-512":
+		c.authProtocol = gosnmp.SHA512
+	default:
+		return errors.New("invalid SNMPv3 authentication protocol")
+	}
+
+	if c.authPassword == "" {
+		return errors.New("no authentication passphrase was given")
+	}
+
+	if c.securityLevel == gosnmp.AuthNoPriv {
+		c.privProtocol = gosnmp.NoPriv
+
+		return nil
+	}
+
+	switch strings.ToUpper(c.privProtocolParam) {
+	case "DES":
+		c.privProtocol = gosnmp.DES
+	case "AES", "AES128", "AES-128":
+		c.privProtocol = gosnmp.AES
+	case "AES192", "AES-192":
+		c.privProtocol = gosnmp.AES192
+	case "AES256", "AES-256":
+		c.privProtocol = gosnmp.AES256
+	case "AES192C", "AES-192C":
+		c.privProtocol = gosnmp.AES192C
+	case "AES256C", "AES-256C":
+		c.privProtocol = gosnmp.AES256C
+	default:
+		return errors.New("invalid SNMPv3 privacy protocol")
+	}
+
+	if c.privPassword == "" {
+		return errors.New("no privacy passphrase was given")
+	}
+
+	return nil
+}
+
+// Splits the time of the check into the timeout and the retries of a single request
+// A request which is not answered at all may use up to half of the time, the rest is left
+// for the other requests and a partial result
+func (c *Config) resolveSNMPTimeout() error {
+	if c.timeout <= 0 {
+		return errors.New("timeout must be positive")
+	}
+
+	if c.snmpTimeoutParam < 0 {
+		return errors.New("snmp-timeout must not be negative")
+	}
+
+	if c.retriesParam < -1 {
+		return errors.New("retries must be -1 or more")
+	}
+
+	budget := c.timeout / 2
+
+	c.retries = c.retriesParam
+	if c.retries == -1 {
+		// As many retries as fit into the budget, but at most two and at least a second per attempt
+		attempt := c.snmpTimeoutParam
+		if attempt == 0 {
+			attempt = time.Second
+		}
+
+		c.retries = min(2, max(0, int(budget/attempt)-1))
+	}
+
+	c.snmpTimeout = c.snmpTimeoutParam
+	if c.snmpTimeout == 0 {
+		c.snmpTimeout = budget / time.Duration(c.retries+1)
+	}
+
+	return nil
+}
+
+// The point in time the check has to stop querying, shortly before the timeout of the plugin
+// so that the result read so far can still be printed
+func (c *Config) deadline() time.Time {
+	return time.Now().Add(c.timeout - min(c.timeout/10, time.Second))
+}
+
 func (c *Config) Run(overall *result.Overall) (err error) {
-	timeout, err := time.ParseDuration("30s")
-	if err != nil {
-		check.ExitError(err)
+	if val, ok := modes[c.mode]; ok && val == serve {
+		return c.serve()
 	}
 
-	params := &gosnmp.GoSNMP{
-		Target:    c.hostname,
-		Port:      c.port,
-		Community: c.community,
-		Version:   c.snmpVersion,
-		Timeout:   timeout,
-		Retries:   3,
+	ctx, cancel := context.WithDeadline(context.Background(), c.deadline())
+	defer cancel()
+
+	if len(c.hosts) > 1 {
+		return c.runHosts(ctx, overall)
 	}
 
-	err = params.Connect()
+	return c.check(ctx, overall)
+}
+
+// Whether the plugin is only run to test that it can be executed
+func (c *Config) isTestRun() bool {
+	val, ok := modes[c.mode]
+
+	return ok && val == runTestSuccess
+}
+
+// Whether the mode prints its own output instead of the result of a check
+func (c *Config) printsOwnOutput() bool {
+	val, ok := modes[c.mode]
+
+	return ok && val == listPossibleSensors
+}
+
+// Checks a single device, when the time is up the sensors read so far are reported
+func (c *Config) check(ctx context.Context, overall *result.Overall) (err error) {
+	client, closeClient, err := c.openClient(ctx)
 	if err != nil {
-		check.ExitError(err)
+		return err
 	}
-	defer params.Conn.Close()
+	defer closeClient()
 
-	// Get name, location and type
-	query, err := params.Get([]string{".1.3.6.1.4.1.3854.3.2.1.9.0", ".1.3.6.1.4.1.3854.3.2.1.10.0", ".1.3.6.1.4.1.3854.3.2.1.8.0"})
+	params := akcp.Client(akcp.NewContextClient(ctx, client))
+
+	if c.record != "" {
+		recorder := capture.NewRecorder(params)
+		params = recorder
+
+		err = akcp.WalkDevice(recorder)
+		if err != nil {
+			c.logVerbose("Walking the device for the capture failed: %s", err)
+		}
+
+		defer func() {
+			recordErr := c.writeRecording(recorder)
+			if err == nil {
+				err = recordErr
+			}
+		}()
+	}
+
+	c.requests = akcp.NewCountingClient(params)
+	defer c.logRequests()
+
+	err = c.queryDevice(c.requests, overall)
+	if errors.Is(err, akcp.ErrDeadline) {
+		c.logVerbose("Querying the device was aborted: %s", err)
+		addTimeoutResult(c.timeout, overall)
+
+		c.timedOut = true
+
+		return nil
+	}
+
+	return err
+}
+
+// Marks the result as incomplete, the results of the sensors read so far are kept
+func addTimeoutResult(timeout time.Duration, overall *result.Overall) {
+	sc := result.PartialResult{}
+	_ = sc.SetDefaultState(check.Unknown)
+	_ = sc.SetState(check.Unknown)
+	sc.Output = fmt.Sprintf("Timeout of %s reached, the result is incomplete", timeout)
+
+	overall.AddSubcheck(sc)
+}
+
+// Reports the number of requests the check needed, if --verbose is given
+func (c *Config) logRequests() {
+	if c.requests == nil {
+		return
+	}
+
+	gets, walks := c.requests.Requests()
+	c.logVerbose("Queried the device with %d GET requests and %d walks", gets, walks)
+}
+
+func (c *Config) writeRecording(recorder *capture.Recorder) error {
+	file := capture.File{
+		Created: time.Now().UTC().Format(time.RFC3339),
+		Device:  akcp.GetDeviceTypeName(c.deviceType),
+		Mode:    c.mode,
+	}
+
+	return recorder.WriteFile(c.record, file, []string{c.community, c.username, c.authPassword, c.privPassword})
+}
+
+// Returns the capture given by --from-walk or a connection to the device
+// The requests to the device are aborted once the context is done
+func (c *Config) openClient(ctx context.Context) (akcp.Client, func(), error) {
+	if c.fromWalk != "" {
+		replay, err := capture.LoadFile(c.fromWalk)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return replay, func() {}, nil
+	}
+
+	openSession := func() (akcp.Client, func(), error) {
+		return c.openSession(ctx)
+	}
+
+	session, closeSession, err := openSession()
 	if err != nil {
-		check.ExitError(err)
+		return nil, nil, err
 	}
 
-	name := query.Variables[0].Value
-	location := query.Variables[1].Value
-	devType := query.Variables[2].Value
+	if c.parallel == 1 {
+		return session, closeSession, nil
+	}
 
-	overall.Summary = fmt.Sprintf("Device %s at location %s (%s)", name, location, devType)
+	// Every concurrent request uses a session of its own
+	pool := akcp.NewSessionPool(session, closeSession, openSession, c.parallel)
 
-	val, ok := modes[c.mode]
-	if !ok { //nolint:nestif
-		// not one of the main modes, look for specifics
-		if c.deviceType == akcp.SensorProbePlusType {
-			val, ok := sensorProbePlus.SensorsTypes[c.mode]
-			if !ok {
-				return errors.New("mode is not a valid value")
-			}
+	return pool, pool.Close, nil
+}
 
-			err = querySensorByType(params, c, overall, c.deviceType, uint64(val))
+func (c *Config) openSession(ctx context.Context) (akcp.Client, func(), error) {
+	snmp, err := c.connect(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
 
-			if err != nil {
-				check.ExitError(err)
-			}
+	return snmp, func() { _ = snmp.Conn.Close() }, nil
+}
 
-			return nil
+func (c *Config) connect(ctx context.Context) (*gosnmp.GoSNMP, error) {
+	params := &gosnmp.GoSNMP{
+		// IPv6 addresses may be given in brackets as in URLs
+		Target:    strings.TrimSuffix(strings.TrimPrefix(c.hostname, "["), "]"),
+		Port:      c.port,
+		Transport: c.transport,
+		Community: c.community,
+		Version:   c.snmpVersion,
+		Timeout:   c.snmpTimeout,
+		Retries:   c.retries,
+		Context:   ctx,
+	}
+
+	if c.sourceAddress != "" {
+		params.LocalAddr = net.JoinHostPort(strings.TrimSuffix(strings.TrimPrefix(c.sourceAddress, "["), "]"), "0")
+	}
+
+	if c.snmpVersion == gosnmp.Version3 {
+		params.SecurityModel = gosnmp.UserSecurityModel
+		params.MsgFlags = c.securityLevel
+		params.ContextName = c.contextName
+		params.SecurityParameters = &gosnmp.UsmSecurityParameters{
+			UserName:                 c.username,
+			AuthenticationProtocol:   c.authProtocol,
+			AuthenticationPassphrase: c.authPassword,
+			PrivacyProtocol:          c.privProtocol,
+			PrivacyPassphrase:        c.privPassword,
 		}
+	}
 
-		// TODO
-		return errors.New("device not yet implemented")
+	err := params.Connect()
+	if err != nil {
+		return nil, err
 	}
 
-	switch val {
-	case queryAllSensors:
-		err = queryAllSensorsMode(params, c, overall, c.deviceType)
+	return params, nil
+}
+
+// Runs the selected mode against the device
+func (c *Config) queryDevice(params akcp.Client, overall *result.Overall) (err error) {
+	if c.deviceType == akcp.AutoDetectType {
+		c.deviceType, err = akcp.DetectDeviceType(params)
 		if err != nil {
-			check.ExitError(err)
+			return err
 		}
 
-		return nil
-	case temperaturSensors:
-		err = queryTemperatureSensors(params, c, overall, c.deviceType)
+		c.logVerbose("Detected device type: %s", akcp.GetDeviceTypeName(c.deviceType))
+
+		err = c.resolveSensorTypes()
 		if err != nil {
-			check.ExitError(err)
+			return err
 		}
+	}
 
-		return nil
-	case humiditySensors:
-		err = queryHumiditySensors(params, c, overall, c.deviceType)
+	// Get name, location and type
+	identity, err := akcp.QueryDeviceIdentity(params, c.deviceType)
+	if err != nil {
+		return err
+	}
+
+	overall.Summary = fmt.Sprintf("Device %s at location %s (%s)", identity.Name, identity.Location, identity.Type)
+	c.report.Device = newDeviceReport(identity)
+
+	val, ok := modes[c.mode]
+	if !ok {
+		// not one of the main modes, look for specifics
+		val, err := akcp.GetSensorTypeInt(c.mode, c.deviceType)
 		if err != nil {
-			check.ExitError(err)
+			return errors.New("mode is not a valid value")
 		}
 
-		return nil
+		return querySensorByType(params, c, overall, c.deviceType, uint64(val))
+	}
+
+	switch val {
+	case queryAllSensors:
+		return queryAllSensorsMode(params, c, overall, c.deviceType)
+	case temperaturSensors:
+		return queryTemperatureSensors(params, c, overall, c.deviceType)
+	case humiditySensors:
+		return queryHumiditySensors(params, c, overall, c.deviceType)
+	case airflowSensors:
+		return queryAirflowSensors(params, c, overall, c.deviceType)
+	case single:
+		return querySingleSensor(params, c, overall, c.deviceType)
+	case listPossibleSensors:
+		return listPossibleSensorsMode(params, c, c.deviceType)
 	default:
 		return errors.New("not yet implemented")
 	}
 }
 
-// nolint: gocognit
-func queryAllSensorsMode(params *gosnmp.GoSNMP, c *Config, overall *result.Overall, deviceType int) (err error) {
-	sensors, err := akcp.QuerySensorList(params, deviceType) // Get all sensors
-	if err != nil {
-		check.ExitError(err)
+// Prints additional information on stderr if --verbose is given
+func (c *Config) logVerbose(format string, args ...any) {
+	if c.verbose {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
 	}
+}
 
-	for _, sensor := range sensors {
-		details, err := akcp.QuerySensorDetails(params, sensor, deviceType)
-
-		if err != nil {
-			check.ExitError(err)
+// Maps the errors of the akcp package to the state of the check and adds hints on what to check
+func (c *Config) explainError(err error) (int, error) {
+	switch {
+	case errors.Is(err, akcp.ErrTimeout):
+		hint := "check --host, --port and the community string"
+		if c.snmpVersion == gosnmp.Version3 {
+			hint = "check --host, --port and the SNMPv3 credentials"
 		}
 
+		return check.Critical, fmt.Errorf("%w - waited %s with %d retries, %s", err, c.snmpTimeout, c.retries, hint)
+	case errors.Is(err, akcp.ErrAuthentication):
+		return check.Unknown, mapSNMPv3Error(err)
+	case errors.Is(err, akcp.ErrNoSuchObject), errors.Is(err, akcp.ErrNoSuchInstance):
+		return check.Unknown, fmt.Errorf("%w - the device does not provide the requested value, check --device, --mode and --sensorPort", err)
+	case errors.Is(err, akcp.ErrUnsupportedDevice):
+		return check.Unknown, fmt.Errorf("%w - check --device", err)
+	case errors.Is(err, akcp.ErrDecode):
+		return check.Unknown, fmt.Errorf("%w - unexpected answer of the device, please report it with a capture (--record)", err)
+	default:
+		return check.Unknown, err
+	}
+}
+
+// Translates the USM report errors of gosnmp into something a user can act upon
+func mapSNMPv3Error(err error) error {
+	switch {
+	case errors.Is(err, gosnmp.ErrUnknownUsername):
+		return fmt.Errorf("SNMPv3 user is unknown to the device, check --username: %w", err)
+	case errors.Is(err, gosnmp.ErrWrongDigest):
+		return fmt.Errorf("SNMPv3 authentication failed, check --auth-protocol and --auth-passphrase: %w", err)
+	case errors.Is(err, gosnmp.ErrDecryption):
+		return fmt.Errorf("SNMPv3 decryption failed, check --priv-protocol and --priv-passphrase: %w", err)
+	case errors.Is(err, gosnmp.ErrUnknownSecurityLevel):
+		return fmt.Errorf("SNMPv3 security level is not supported for this user, check --security-level: %w", err)
+	case errors.Is(err, gosnmp.ErrNotInTimeWindow):
+		return fmt.Errorf("SNMPv3 message was outside of the time window of the device: %w", err)
+	case errors.Is(err, gosnmp.ErrUnknownEngineID):
+		return fmt.Errorf("SNMPv3 engine ID was not accepted by the device: %w", err)
+	default:
+		return err
+	}
+}
+
+// nolint: gocognit
+func queryAllSensorsMode(params akcp.Client, c *Config, overall *result.Overall, deviceType int) (err error) {
+	// When the time is up, the sensors read so far are checked nonetheless
+	sensors, err := akcp.QueryAllSensorDetails(params, deviceType)
+	if err != nil && !errors.Is(err, akcp.ErrDeadline) {
+		return err
+	}
+
+	selected := make([]akcp.SensorDetails, 0, len(sensors))
+
+	for _, details := range sensors {
 		exclude := false
 
 		for _, excludedType := range c.excludeSensorTypeInteger {
@@ -317,54 +826,102 @@ func queryAllSensorsMode(params *gosnmp.GoSNMP, c *Config, overall *result.Overa
 			}
 		}
 
-		if exclude {
+		if exclude || !c.filter.matches(details) {
 			continue
 		}
 
-		if details.SensorType == sensorProbePlus.Temperature ||
-			details.SensorType == sensorProbePlus.Temperature_dual {
-			tempSensors, err := akcp.QueryTemperatureTable(params, deviceType)
+		selected = append(selected, details)
+	}
 
-			if err != nil {
-				return err
-			}
+	return errors.Join(err, c.addSensorResults(params, selected, overall))
+}
 
-			for _, tempSensor := range tempSensors {
-				if details.Name == tempSensor.Name {
-					details.Warning = tempSensor.Warning
-					details.Critical = tempSensor.Critical
-				}
-			}
-		}
+func querySingleSensor(params akcp.Client, c *Config, overall *result.Overall, deviceType int) error {
+	sensorIndex, err := akcp.FindSensor(params, c.sensorPort, deviceType)
+	if err != nil {
+		return err
+	}
 
-		if details.SensorType == sensorProbePlus.Humidity_dual {
-			humiSensors, err := akcp.QueryHumidityTable(params, deviceType)
+	details, err := akcp.QuerySensorDetails(params, sensorIndex, deviceType)
+	if err != nil {
+		return err
+	}
 
-			if err != nil {
-				return err
-			}
+	return c.addSensorResults(params, []akcp.SensorDetails{details}, overall)
+}
 
-			for _, humSensor := range humiSensors {
-				if details.Name == humSensor.Name {
-					details.Warning = humSensor.Warning
-					details.Critical = humSensor.Critical
-				}
-			}
+// Completes the sensors from the tables of their types and adds them to the result
+func (c *Config) addSensorResults(params akcp.Client, sensors []akcp.SensorDetails, overall *result.Overall) error {
+	// Without the tables of the types the state of the device is used, the thresholds are missing
+	// in the performance data then
+	err := akcp.AddTypeTableDetails(params, sensors, c.deviceType)
+	if err != nil && !errors.Is(err, akcp.ErrDeadline) {
+		return err
+	}
+
+	for _, sensor := range sensors {
+		addErr := c.addSensorResult(sensor, overall)
+		if addErr != nil {
+			return addErr
 		}
+	}
+
+	return err
+}
+
+// Evaluates the sensor against the thresholds given by the user and adds it to the result
+func (c *Config) addSensorResult(sensor akcp.SensorDetails, overall *result.Overall) error {
+	if sensor.Err != nil {
+		addSensorError(sensor, overall)
+	} else {
+		applyCurrentScales(&sensor, c.currentScales)
+		applyThresholds(&sensor, c.warning, c.critical)
+		applyContactStates(&sensor, c.contactStates)
+		applyAirflow(&sensor, c.airflowStoppedCritical)
 
-		err = mapSensorStatus(details, overall)
+		err := mapSensorStatus(sensor, overall)
 		if err != nil {
 			return err
 		}
 	}
 
+	state := overall.PartialResults[len(overall.PartialResults)-1].GetStatus()
+	c.report.Sensors = append(c.report.Sensors, newSensorReport(sensor, c.deviceType, state))
+
 	return nil
 }
 
+// A sensor which could not be read is UNKNOWN, the other sensors are evaluated nonetheless
+func addSensorError(sensor akcp.SensorDetails, overall *result.Overall) {
+	name := sensor.Name
+	if name == "" {
+		name = "Sensor " + sensor.Index
+	}
+
+	sc := result.PartialResult{}
+	_ = sc.SetDefaultState(check.Unknown)
+	_ = sc.SetState(check.Unknown)
+	sc.Output = fmt.Sprintf("%s could not be read: %s", name, sensor.Err)
+
+	overall.AddSubcheck(sc)
+}
+
 func mapSensorStatus(sensor akcp.SensorDetails, overall *result.Overall) error {
+	if akcp.IsWaterRope(sensor.SensorType) {
+		mapWaterRopeStatus(sensor, overall)
+
+		return nil
+	}
+
 	var sensorString string
 	if sensor.SensorType == sensorProbePlus.Motion {
 		sensorString = fmt.Sprintf("%s: %s", sensor.Name, sensor.Description)
+	} else if state := sensor.ContactState(); akcp.IsContact(sensor.SensorType) && state != akcp.ContactStateUnknown {
+		// Dry contacts have no value, only their state
+		sensorString = fmt.Sprintf("%s: %s", sensor.Name, state)
+		if state != sensor.NormalState {
+			sensorString += fmt.Sprintf(" (expected %s)", sensor.NormalState)
+		}
 	} else {
 		sensorString = fmt.Sprintf("%s: %.1f", sensor.Name, sensor.Value)
 	}
@@ -391,11 +948,7 @@ func mapSensorStatus(sensor akcp.SensorDetails, overall *result.Overall) error {
 		pf.Crit = &sensor.Critical.Val
 	}
 
-	if sensor.Unit != "C" {
-		pf.Uom = strings.ToLower(sensor.Unit)
-	} else {
-		pf.Uom = "C"
-	}
+	pf.Uom = perfdataUnit(sensor.Unit)
 
 	sc := result.PartialResult{}
 	_ = sc.SetDefaultState(check.Unknown)
@@ -425,36 +978,52 @@ func mapSensorStatus(sensor akcp.SensorDetails, overall *result.Overall) error {
 	return nil
 }
 
-func querySensorByType(params *gosnmp.GoSNMP, _ *Config, overall *result.Overall, deviceType int, sensorType uint64) error { //nolint:unparam
-	sensors, err := akcp.QuerySensorList(params, deviceType) // Get all sensors
+// Maps the units of the device which have a common spelling in the performance data,
+// all other units are passed through unchanged
+func perfdataUnit(unit string) string {
+	switch unit {
+	case "℃", "°C":
+		return "C"
+	case "℉", "°F":
+		return "F"
+	case "%RH":
+		return "%"
+	case "VAC", "VDC":
+		return "V"
+	default:
+		return unit
+	}
+}
 
-	if err != nil {
-		check.ExitError(err)
+func querySensorByType(params akcp.Client, c *Config, overall *result.Overall, deviceType int, sensorType uint64) error {
+	sensors, err := akcp.QueryAllSensorDetails(params, deviceType)
+	if err != nil && !errors.Is(err, akcp.ErrDeadline) {
+		return err
 	}
 
-	for _, sensor := range sensors {
-		details, err := akcp.QuerySensorDetails(params, sensor, deviceType)
-		if err != nil {
-			check.ExitError(err)
-		}
+	selected := make([]akcp.SensorDetails, 0, len(sensors))
 
-		if details.SensorType == sensorType {
-			err = mapSensorStatus(details, overall)
-			if err != nil {
-				check.ExitError(err)
-			}
+	for _, details := range sensors {
+		if details.SensorType == sensorType && c.filter.matches(details) {
+			selected = append(selected, details)
 		}
 	}
 
-	return nil
+	return errors.Join(err, c.addSensorResults(params, selected, overall))
 }
 
-func queryTemperatureSensors(params *gosnmp.GoSNMP, _ *Config, overall *result.Overall, deviceType int) (err error) {
-	sensors, _ := akcp.QueryTemperatureTable(params, deviceType) // Get all sensors
-	// TODO: Error Handling
+func queryTemperatureSensors(params akcp.Client, c *Config, overall *result.Overall, deviceType int) (err error) {
+	sensors, err := akcp.QueryTemperatureTable(params, deviceType) // Get all sensors
+	if err != nil {
+		return err
+	}
 
 	for _, details := range sensors {
-		err = mapSensorStatus(details, overall)
+		if !c.filter.matches(details) {
+			continue
+		}
+
+		err = c.addSensorResult(details, overall)
 		if err != nil {
 			return err
 		}
@@ -463,14 +1032,18 @@ func queryTemperatureSensors(params *gosnmp.GoSNMP, _ *Config, overall *result.O
 	return nil
 }
 
-func queryHumiditySensors(params *gosnmp.GoSNMP, _ *Config, overall *result.Overall, deviceType int) (err error) {
+func queryHumiditySensors(params akcp.Client, c *Config, overall *result.Overall, deviceType int) (err error) {
 	sensors, err := akcp.QueryHumidityTable(params, deviceType) // Get all sensors
 	if err != nil {
-		check.ExitError(err)
+		return err
 	}
 
 	for _, sensor := range sensors {
-		err = mapSensorStatus(sensor, overall)
+		if !c.filter.matches(sensor) {
+			continue
+		}
+
+		err = c.addSensorResult(sensor, overall)
 		if err != nil {
 			return err
 		}
@@ -1,15 +1,59 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
 	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/capture"
 	"github.com/NETWAYS/go-check"
 	"github.com/NETWAYS/go-check/result"
+	"github.com/gosnmp/gosnmp"
 )
 
+// Completes the configuration with the defaults of the flags and validates it
+func newTestConfig(t *testing.T, config Config) *Config {
+	t.Helper()
+
+	if config.device == "" {
+		config.device = "auto"
+	}
+
+	config.snmpVersionParam = "2c"
+	config.outputFormat = "text"
+	config.parallel = 1
+	config.timeout = 30 * time.Second
+	config.retriesParam = -1
+	config.transport = "udp"
+
+	err := config.Validate()
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	return &config
+}
+
+// Runs the check of the configuration, usually against a capture given by fromWalk
+func runTestConfig(t *testing.T, config Config) *result.Overall {
+	t.Helper()
+
+	overall := &result.Overall{}
+
+	err := newTestConfig(t, config).Run(overall)
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	return overall
+}
+
 func TestSensorStatus(t *testing.T) {
 
 	testcases := map[string]struct {
@@ -70,3 +114,447 @@ func TestSensorStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateSNMPv3(t *testing.T) {
+	testcases := map[string]struct {
+		config      Config
+		expectError bool
+	}{
+		"authPriv": {
+			config: Config{
+				username:           "monitoring",
+				securityLevelParam: "authPriv",
+				authProtocolParam:  "SHA-256",
+				authPassword:       "secret123",
+				privProtocolParam:  "AES-256",
+				privPassword:       "secret456",
+			},
+		},
+		"noAuthNoPriv": {
+			config: Config{
+				username:           "monitoring",
+				securityLevelParam: "noAuthNoPriv",
+			},
+		},
+		"missingUser": {
+			config: Config{
+				securityLevelParam: "noAuthNoPriv",
+			},
+			expectError: true,
+		},
+		"missingPrivPassphrase": {
+			config: Config{
+				username:           "monitoring",
+				securityLevelParam: "authPriv",
+				authProtocolParam:  "MD5",
+				authPassword:       "secret123",
+				privProtocolParam:  "DES",
+			},
+			expectError: true,
+		},
+		"invalidAuthProtocol": {
+			config: Config{
+				username:           "monitoring",
+				securityLevelParam: "authNoPriv",
+				authProtocolParam:  "SHA-3",
+				authPassword:       "secret123",
+			},
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.config.validateSNMPv3()
+
+			if tc.expectError && err == nil {
+				t.Error("Expected an error, got nil")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Error("Expected no error, got ", err)
+			}
+		})
+	}
+}
+
+func TestRunFromWalk(t *testing.T) {
+	testcases := map[string]struct {
+		config   Config
+		expected []string
+	}{
+		"sensorProbePlusAllSensors": {
+			config: Config{
+				fromWalk: "testdata/sensorProbePlus.walk",
+				mode:     "queryAllSensors",
+			},
+			expected: []string{
+				"Device SPX+ Demo at location Room 217",
+				"[OK] Temperature Port 1: 27.1℃",
+				"[WARNING] Dual Humidity Port 2: 25.0%",
+				"[WARNING] Dual Temperature Port 2: 31.1℃",
+				"'Dual Humidity Port 2'=25%;32:66;23:69",
+				"'Temperature Port 1'=27.1C;20.7:30;10.6:40",
+			},
+		},
+		"sensorProbePlusSingle": {
+			config: Config{
+				fromWalk:   "testdata/sensorProbePlus.walk",
+				mode:       "single",
+				sensorPort: "2.2",
+			},
+			expected: []string{
+				"\\_ [WARNING] Dual Temperature Port 2: 31.1℃\n|",
+			},
+		},
+		"sensorProbePlusByName": {
+			config: Config{
+				fromWalk:   "testdata/sensorProbePlus.walk",
+				mode:       "single",
+				sensorPort: "Airflow Port 3",
+			},
+			expected: []string{
+				"\\_ [OK] Airflow Port 3: 0.0%\n|",
+			},
+		},
+		"sensorProbeAllSensors": {
+			config: Config{
+				fromWalk: "testdata/sensorProbe.walk",
+				mode:     "queryAllSensors",
+			},
+			expected: []string{
+				"Device SP8 Cold Room at location Basement",
+				"[OK] Freezer: -22.0℃",
+				"[OK] Freezer Humidity: 45.0%",
+				"[CRITICAL] Door Contact: open (expected closed)",
+				"Freezer=-22C;-28:-15;-30:-10",
+			},
+		},
+		"securityProbeAllSensors": {
+			config: Config{
+				fromWalk: "testdata/securityProbe.walk",
+				mode:     "queryAllSensors",
+			},
+			expected: []string{
+				"Device SEC5E Server Room at location Data Center",
+				"[OK] Rack Inlet: 23.0℃",
+				"[CRITICAL] Door Contact: open (expected closed)",
+				"[OK] Cage Motion: 0.0",
+				"[OK] Alarm Siren: 0.0",
+				"'Rack Inlet'=23C;15:30;10:35",
+			},
+		},
+		"sensorProbePlusContacts": {
+			config: Config{
+				fromWalk: "testdata/sensorProbePlusContacts.walk",
+				mode:     "queryAllSensors",
+			},
+			expected: []string{
+				"[OK] Server Room Door: closed",
+				"[CRITICAL] UPS Alarm: closed (expected open)",
+				"[OK] CRAC Fault Output: closed",
+			},
+		},
+		"sensorProbePlusCurrentLoop": {
+			config: Config{
+				fromWalk:          "testdata/sensorProbePlusAnalog.walk",
+				mode:              "four_20mA",
+				currentScaleParam: []string{"/^Tank/=0:10000:l"},
+			},
+			expected: []string{
+				"[OK] Diff Pressure: 125.0Pa",
+				"[OK] Tank Level: 5000.0l",
+				"'Diff Pressure'=125Pa;20:300;10:400",
+				"'Tank Level'=5000l;625:8750;9375",
+			},
+		},
+		"sensorProbePlusVoltage": {
+			config: Config{
+				fromWalk: "testdata/sensorProbePlusAnalog.walk",
+				mode:     "queryAllSensors",
+			},
+			expected: []string{
+				"[OK] Battery Voltage: 53.2V",
+				"[OK] Mains Voltage: 231.0VAC",
+				"'Battery Voltage'=53.2V;46:56;44:58",
+				"'Mains Voltage'=231V;207:253;190:264",
+			},
+		},
+		"sensorProbePlusAirflow": {
+			config: Config{
+				fromWalk: "testdata/sensorProbePlusAirflow.walk",
+				mode:     "airflowSensors",
+			},
+			expected: []string{
+				"[OK] Rack Fan 1: 85.0%",
+				"[OK] Rack Fan 2: 0.0%",
+				"[WARNING] CRAC Airflow: 15.0%",
+				"'Rack Fan 1'=85%;20:;10:",
+			},
+		},
+		"sensorProbePlusAirflowStopped": {
+			config: Config{
+				fromWalk:               "testdata/sensorProbePlusAirflow.walk",
+				mode:                   "airflow",
+				criticalParam:          []string{"airflow=25:"},
+				airflowStoppedCritical: true,
+			},
+			expected: []string{
+				"[CRITICAL] Rack Fan 2: 0.0%",
+				"[CRITICAL] CRAC Airflow: 15.0%",
+				"'CRAC Airflow'=15%;20:;25:",
+			},
+		},
+		"sensorProbePlusWaterRope": {
+			config: Config{
+				fromWalk:     "testdata/sensorProbePlusWaterRope.walk",
+				mode:         "waterrope",
+				warningParam: []string{"10:"},
+			},
+			expected: []string{
+				"[OK] Raised Floor A: no leak\n",
+				"[CRITICAL] Raised Floor B: leak at 12.0m of 50.0m\n",
+				"[UNKNOWN] UPS Room: rope broken, leaks can not be detected\n",
+				"|'Raised Floor A'=0m;;;0;30 'Raised Floor B'=12m;;;0;50 'UPS Room'=0ft;;;0;100\n",
+			},
+		},
+		"sensorProbePlusContactsWithThresholds": {
+			config: Config{
+				fromWalk:      "testdata/sensorProbePlusContacts.walk",
+				mode:          "queryAllSensors",
+				warningParam:  []string{"18:27"},
+				criticalParam: []string{"15:32"},
+			},
+			expected: []string{
+				"[OK] Server Room Door: closed\n",
+				"[CRITICAL] UPS Alarm: closed (expected open)\n",
+				"[OK] CRAC Fault Output: closed\n",
+			},
+		},
+		"sensorProbePlusContactStates": {
+			config: Config{
+				fromWalk:          "testdata/sensorProbePlusContacts.walk",
+				mode:              "dry_inout",
+				contactStateParam: []string{"/^CRAC/=open:warning", "closed"},
+			},
+			expected: []string{
+				"[OK] Server Room Door: closed\n",
+				"[WARNING] CRAC Fault Output: closed (expected open)",
+				"'CRAC Fault Output'=1",
+			},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			actual := runTestConfig(t, tc.config).GetOutput()
+
+			for _, expected := range tc.expected {
+				if !strings.Contains(actual, expected) {
+					t.Error("\nActual: ", actual, "\nExpected: ", expected)
+				}
+			}
+		})
+	}
+}
+
+func TestRunWithUnreadableSensor(t *testing.T) {
+	walk, err := os.ReadFile("testdata/sensorProbePlus.walk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A half-connected sensor without a value
+	broken := strings.Replace(string(walk),
+		".1.3.6.1.4.1.3854.3.5.1.1.99.1.3.1.1 = Opaque: 00 00 00 00",
+		".1.3.6.1.4.1.3854.3.5.1.1.99.1.3.1.1 = No Such Instance currently exists at this OID", 1)
+
+	path := filepath.Join(t.TempDir(), "broken.walk")
+
+	err = os.WriteFile(path, []byte(broken), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	overall := runTestConfig(t, Config{fromWalk: path, mode: "queryAllSensors"})
+
+	actual := overall.GetOutput()
+
+	expected := []string{
+		"[OK] Temperature Port 1: 27.1℃",
+		"[UNKNOWN] Airflow Port 3 could not be read: no such instance at .1.3.6.1.4.1.3854.3.5.1.1.99.1.3.1.1",
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(actual, e) {
+			t.Error("\nActual: ", actual, "\nExpected: ", e)
+		}
+	}
+
+	if overall.GetStatus() != check.Unknown {
+		t.Error("\nActual: ", overall.GetStatus(), "\nExpected: ", check.Unknown)
+	}
+}
+
+func TestExplainError(t *testing.T) {
+	testcases := map[string]struct {
+		err           error
+		expectedState int
+		expected      string
+	}{
+		"timeout": {
+			err:           &akcp.Error{Kind: akcp.ErrTimeout, Err: errors.New("request timeout (after 3 retries)")},
+			expectedState: check.Critical,
+			expected:      "check --host, --port and the community string",
+		},
+		"authentication": {
+			err:           &akcp.Error{Kind: akcp.ErrAuthentication, Err: gosnmp.ErrWrongDigest},
+			expectedState: check.Unknown,
+			expected:      "check --auth-protocol and --auth-passphrase",
+		},
+		"noSuchInstance": {
+			err:           &akcp.Error{Kind: akcp.ErrNoSuchInstance, OID: ".1.3.6.1.4.1.3854.3.5.1.1.2.1.1.1.1"},
+			expectedState: check.Unknown,
+			expected:      "no such instance at .1.3.6.1.4.1.3854.3.5.1.1.2.1.1.1.1 - the device does not provide the requested value",
+		},
+		"unsupportedDevice": {
+			err:           &akcp.Error{Kind: akcp.ErrUnsupportedDevice},
+			expectedState: check.Unknown,
+			expected:      "check --device",
+		},
+		"other": {
+			err:           errors.New("connection refused"),
+			expectedState: check.Unknown,
+			expected:      "connection refused",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			config := Config{snmpVersion: gosnmp.Version2c}
+
+			state, err := config.explainError(tc.err)
+
+			if state != tc.expectedState || !strings.Contains(err.Error(), tc.expected) {
+				t.Error("\nActual: ", state, err, "\nExpected: ", tc.expectedState, tc.expected)
+			}
+
+			if !errors.Is(err, tc.err) {
+				t.Error("Expected the original error to be wrapped")
+			}
+		})
+	}
+}
+
+func TestResolveSNMPTimeout(t *testing.T) {
+	testcases := map[string]struct {
+		config          Config
+		expectedTimeout time.Duration
+		expectedRetries int
+	}{
+		"default": {
+			config:          Config{timeout: 30 * time.Second, retriesParam: -1},
+			expectedTimeout: 5 * time.Second,
+			expectedRetries: 2,
+		},
+		"shortTimeout": {
+			config:          Config{timeout: 2 * time.Second, retriesParam: -1},
+			expectedTimeout: time.Second,
+			expectedRetries: 0,
+		},
+		"retries": {
+			config:          Config{timeout: 30 * time.Second, retriesParam: 4},
+			expectedTimeout: 3 * time.Second,
+			expectedRetries: 4,
+		},
+		"snmpTimeout": {
+			config:          Config{timeout: 30 * time.Second, snmpTimeoutParam: 10 * time.Second, retriesParam: -1},
+			expectedTimeout: 10 * time.Second,
+			expectedRetries: 0,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			config := tc.config
+
+			err := config.resolveSNMPTimeout()
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+
+			if config.snmpTimeout != tc.expectedTimeout || config.retries != tc.expectedRetries {
+				t.Error("\nActual: ", config.snmpTimeout, config.retries, "\nExpected: ", tc.expectedTimeout, tc.expectedRetries)
+			}
+		})
+	}
+}
+
+// Cancels the context after a number of walks, as if the time of the check was up
+type cancellingClient struct {
+	akcp.Client
+	walks  int
+	cancel context.CancelFunc
+}
+
+func (c *cancellingClient) BulkWalkAll(rootOid string) ([]gosnmp.SnmpPDU, error) {
+	pdus, err := c.Client.BulkWalkAll(rootOid)
+
+	c.walks--
+	if c.walks == 0 {
+		c.cancel()
+	}
+
+	return pdus, err
+}
+
+func TestQueryAllSensorsUntilDeadline(t *testing.T) {
+	replay, err := capture.LoadFile("testdata/sensorProbe.walk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The temperature table is read completely, the other tables are not
+	params := akcp.NewContextClient(ctx, &cancellingClient{Client: replay, walks: 2, cancel: cancel})
+
+	config := Config{deviceType: akcp.SensorProbeType}
+
+	var overall result.Overall
+
+	err = queryAllSensorsMode(params, &config, &overall, akcp.SensorProbeType)
+	if !errors.Is(err, akcp.ErrDeadline) {
+		t.Fatal("Expected the deadline error, got ", err)
+	}
+
+	if len(overall.PartialResults) != 1 || overall.PartialResults[0].Output != "Freezer: -22.0℃" {
+		t.Error("\nActual: ", overall.GetOutput(), "\nExpected: ", "Freezer: -22.0℃")
+	}
+}
+
+func TestPerfdataUnit(t *testing.T) {
+	testcases := map[string]string{
+		"C":   "C",
+		"°C":  "C",
+		"℃":   "C",
+		"%":   "%",
+		"%RH": "%",
+		"V":   "V",
+		"VAC": "V",
+		"mA":  "mA",
+		"Pa":  "Pa",
+		"kWh": "kWh",
+		"":    "",
+	}
+
+	for unit, expected := range testcases {
+		t.Run(unit, func(t *testing.T) {
+			actual := perfdataUnit(unit)
+			if actual != expected {
+				t.Error("\nActual: ", actual, "\nExpected: ", expected)
+			}
+		})
+	}
+}
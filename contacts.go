@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+)
+
+// The state a dry contact is expected in, given by the user instead of the normal state
+// configured on the device, optionally limited to contacts selected as in sensorSelector.
+// A contact in the other state is CRITICAL, or WARNING if given.
+//
+// Format: [<selector>=]<open|closed>[:warning|critical]
+type contactRule struct {
+	sensorSelector
+	expected  akcp.ContactState
+	deviation akcp.SensorStatus
+}
+
+type contactRules []contactRule
+
+func parseContactRules(specs []string) (contactRules, error) {
+	rules := make(contactRules, 0, len(specs))
+
+	for _, spec := range specs {
+		selector, stateSpec, err := parseSensorSelector(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid contact state: %w", err)
+		}
+
+		rule := contactRule{sensorSelector: selector}
+
+		state, severity, _ := strings.Cut(stateSpec, ":")
+
+		switch strings.ToLower(state) {
+		case "open":
+			rule.expected = akcp.ContactOpen
+		case "closed":
+			rule.expected = akcp.ContactClosed
+		default:
+			return nil, fmt.Errorf("invalid contact state in %s, must be open or closed", spec)
+		}
+
+		switch strings.ToLower(severity) {
+		case "", "critical":
+			rule.deviation = akcp.HighCritical
+		case "warning":
+			rule.deviation = akcp.HighWarning
+		default:
+			return nil, fmt.Errorf("invalid state for a deviating contact in %s, must be warning or critical", spec)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// Replaces the normal state of a dry contact with the state given by the user and
+// evaluates the contact against it.
+// Contacts which are in an unknown state (e.g. the sensor is in error) keep the status of the device.
+func applyContactStates(sensor *akcp.SensorDetails, rules contactRules) {
+	if !akcp.IsContact(sensor.SensorType) {
+		return
+	}
+
+	rule, found := lookupRule(rules, *sensor)
+	if !found {
+		return
+	}
+
+	state := sensor.ContactState()
+	if state == akcp.ContactStateUnknown {
+		return
+	}
+
+	sensor.NormalState = rule.expected
+
+	// The value is 1 if the contact is not in its normal state, as reported by the device
+	if state == rule.expected {
+		sensor.Status = akcp.Normal
+		sensor.Value = 0
+	} else {
+		sensor.Status = rule.deviation
+		sensor.Value = 1
+	}
+}
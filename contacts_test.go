@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+)
+
+func TestApplyContactStates(t *testing.T) {
+	// The door is closed, as configured on the device
+	door := akcp.SensorDetails{
+		Index:       "1.1.1.1",
+		Name:        "Server Room Door",
+		SensorType:  sensorProbePlus.Dry_inout,
+		Status:      akcp.Normal,
+		NormalState: akcp.ContactClosed,
+	}
+
+	// The alarm relay of the UPS is closed, but open on the device
+	ups := akcp.SensorDetails{
+		Index:       "1.2.1.1",
+		Name:        "UPS Alarm",
+		SensorType:  sensorProbePlus.Dry_in,
+		Status:      akcp.HighCritical,
+		Value:       1,
+		NormalState: akcp.ContactOpen,
+	}
+
+	testcases := map[string]struct {
+		rules          []string
+		sensor         akcp.SensorDetails
+		expectedStatus akcp.SensorStatus
+		expectedValue  float64
+	}{
+		"noRule": {
+			sensor:         ups,
+			expectedStatus: akcp.HighCritical,
+			expectedValue:  1,
+		},
+		"expectedState": {
+			rules:          []string{"closed"},
+			sensor:         ups,
+			expectedStatus: akcp.Normal,
+			expectedValue:  0,
+		},
+		"otherState": {
+			rules:          []string{"open"},
+			sensor:         door,
+			expectedStatus: akcp.HighCritical,
+			expectedValue:  1,
+		},
+		"warning": {
+			rules:          []string{"open:warning"},
+			sensor:         door,
+			expectedStatus: akcp.HighWarning,
+			expectedValue:  1,
+		},
+		"nameWinsOverPort": {
+			rules:          []string{"/^UPS/=closed", "2=open"},
+			sensor:         ups,
+			expectedStatus: akcp.Normal,
+			expectedValue:  0,
+		},
+		"otherPort": {
+			rules:          []string{"2=open"},
+			sensor:         door,
+			expectedStatus: akcp.Normal,
+			expectedValue:  0,
+		},
+		"noContact": {
+			rules:          []string{"open"},
+			sensor:         akcp.SensorDetails{Name: "Temperature", SensorType: sensorProbePlus.Temperature, Status: akcp.Normal},
+			expectedStatus: akcp.Normal,
+		},
+		"sensorError": {
+			rules:          []string{"open"},
+			sensor:         akcp.SensorDetails{Name: "Door", SensorType: sensorProbePlus.Dry_inout, Status: akcp.SensorError, NormalState: akcp.ContactClosed},
+			expectedStatus: akcp.SensorError,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			rules, err := parseContactRules(tc.rules)
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+
+			sensor := tc.sensor
+			applyContactStates(&sensor, rules)
+
+			if sensor.Status != tc.expectedStatus || sensor.Value != tc.expectedValue {
+				t.Error("\nActual: ", sensor.Status, sensor.Value, "\nExpected: ", tc.expectedStatus, tc.expectedValue)
+			}
+		})
+	}
+}
+
+func TestParseContactRulesErrors(t *testing.T) {
+	testcases := map[string][]string{
+		"invalidState":    {"on"},
+		"invalidSeverity": {"closed:unknown"},
+		"invalidPattern":  {"/[/=closed"},
+		"invalidRange":    {"4-2=open"},
+	}
+
+	for name, specs := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseContactRules(specs)
+			if err == nil {
+				t.Error("Expected an error for ", specs)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+	"github.com/NETWAYS/go-check"
+)
+
+// The engineering values of 4 mA and 20 mA of a current loop sensor, given by the user
+// instead of the mapping configured on the device, optionally limited to sensors selected
+// as in sensorSelector.
+//
+// Format: [<selector>=]<value at 4 mA>:<value at 20 mA>[:<unit>]
+type currentScaleRule struct {
+	sensorSelector
+	loopRange akcp.CurrentLoopRange
+	unit      string
+}
+
+type currentScaleRules []currentScaleRule
+
+func parseCurrentScaleRules(specs []string) (currentScaleRules, error) {
+	rules := make(currentScaleRules, 0, len(specs))
+
+	for _, spec := range specs {
+		selector, scaleSpec, err := parseSensorSelector(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid current scale: %w", err)
+		}
+
+		rule := currentScaleRule{sensorSelector: selector}
+
+		parts := strings.SplitN(scaleSpec, ":", 3)
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid current scale %s, the values of 4 mA and 20 mA are required", spec)
+		}
+
+		rule.loopRange.Low, err = strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of 4 mA in current scale %s: %w", spec, err)
+		}
+
+		rule.loopRange.High, err = strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of 20 mA in current scale %s: %w", spec, err)
+		}
+
+		if !rule.loopRange.Configured() {
+			return nil, errors.New("invalid current scale " + spec + ", the values of 4 mA and 20 mA must differ")
+		}
+
+		if len(parts) == 3 {
+			rule.unit = parts[2]
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// Converts the value of a 4-20 mA sensor and the thresholds of the device into the
+// engineering units given by the user
+// The status of the device is kept, it does not depend on the units.
+func applyCurrentScales(sensor *akcp.SensorDetails, rules currentScaleRules) {
+	if sensor.SensorType != sensorProbePlus.Four_20mA {
+		return
+	}
+
+	rule, found := lookupRule(rules, *sensor)
+	if !found {
+		return
+	}
+
+	deviceRange := sensor.LoopRange
+
+	convert := func(value float64) float64 {
+		if deviceRange.Configured() {
+			value = deviceRange.ToCurrent(value)
+		}
+
+		return rule.loopRange.ToValue(value)
+	}
+
+	sensor.Value = convert(sensor.Value)
+
+	if sensor.Warning.Present {
+		sensor.Warning.Val = convertThreshold(sensor.Warning.Val, convert)
+	}
+
+	if sensor.Critical.Present {
+		sensor.Critical.Val = convertThreshold(sensor.Critical.Val, convert)
+	}
+
+	sensor.LoopRange = rule.loopRange
+	if rule.unit != "" {
+		sensor.Unit = rule.unit
+	}
+}
+
+// A falling scale (e.g. 20 mA for an empty tank) swaps the bounds of the threshold
+func convertThreshold(threshold check.Threshold, convert func(float64) float64) check.Threshold {
+	lower := convert(threshold.Lower)
+	upper := convert(threshold.Upper)
+
+	if lower > upper {
+		lower, upper = upper, lower
+	}
+
+	threshold.Lower = lower
+	threshold.Upper = upper
+
+	return threshold
+}
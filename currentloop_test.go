@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+	"github.com/NETWAYS/go-check"
+)
+
+func TestApplyCurrentScales(t *testing.T) {
+	// The device is not configured, the value is the current in mA
+	unconfigured := akcp.SensorDetails{
+		Index:      "1.2.1.1",
+		Name:       "Tank Level",
+		SensorType: sensorProbePlus.Four_20mA,
+		Value:      12,
+		Unit:       "mA",
+		Warning:    akcp.MayThreshold{Present: true, Val: check.Threshold{Lower: 5, Upper: 18}},
+	}
+
+	// The device maps the loop to 0 to 500 Pa
+	configured := akcp.SensorDetails{
+		Index:      "1.1.1.1",
+		Name:       "Diff Pressure",
+		SensorType: sensorProbePlus.Four_20mA,
+		Value:      125,
+		Unit:       "Pa",
+		Critical:   akcp.MayThreshold{Present: true, Val: check.Threshold{Lower: 0, Upper: math.Inf(1)}},
+		LoopRange:  akcp.CurrentLoopRange{Low: 0, High: 500},
+	}
+
+	testcases := map[string]struct {
+		rules            []string
+		sensor           akcp.SensorDetails
+		expectedValue    float64
+		expectedUnit     string
+		expectedWarning  check.Threshold
+		expectedCritical check.Threshold
+	}{
+		"noRule": {
+			sensor:          unconfigured,
+			expectedValue:   12,
+			expectedUnit:    "mA",
+			expectedWarning: check.Threshold{Lower: 5, Upper: 18},
+		},
+		"unconfigured": {
+			rules:           []string{"/^Tank/=0:10000:l"},
+			sensor:          unconfigured,
+			expectedValue:   5000,
+			expectedUnit:    "l",
+			expectedWarning: check.Threshold{Lower: 625, Upper: 8750},
+		},
+		"configured": {
+			rules:            []string{"1=0:5:kPa"},
+			sensor:           configured,
+			expectedValue:    1.25,
+			expectedUnit:     "kPa",
+			expectedCritical: check.Threshold{Lower: 0, Upper: math.Inf(1)},
+		},
+		"fallingScale": {
+			rules:           []string{"100:0"},
+			sensor:          unconfigured,
+			expectedValue:   50,
+			expectedUnit:    "mA",
+			expectedWarning: check.Threshold{Lower: 12.5, Upper: 93.75},
+		},
+		"otherPort": {
+			rules:           []string{"1=0:10000:l"},
+			sensor:          unconfigured,
+			expectedValue:   12,
+			expectedUnit:    "mA",
+			expectedWarning: check.Threshold{Lower: 5, Upper: 18},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			rules, err := parseCurrentScaleRules(tc.rules)
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+
+			sensor := tc.sensor
+			applyCurrentScales(&sensor, rules)
+
+			if sensor.Value != tc.expectedValue || sensor.Unit != tc.expectedUnit {
+				t.Error("\nActual: ", sensor.Value, sensor.Unit, "\nExpected: ", tc.expectedValue, tc.expectedUnit)
+			}
+
+			if sensor.Warning.Val != tc.expectedWarning || sensor.Critical.Val != tc.expectedCritical {
+				t.Error("\nActual: ", sensor.Warning.Val, sensor.Critical.Val, "\nExpected: ", tc.expectedWarning, tc.expectedCritical)
+			}
+		})
+	}
+}
+
+func TestParseCurrentScaleRulesErrors(t *testing.T) {
+	testcases := map[string][]string{
+		"missingHigh":  {"0"},
+		"invalidLow":   {"low:100"},
+		"equalValues":  {"5:5"},
+		"invalidRange": {"4-2=0:100"},
+	}
+
+	for name, specs := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseCurrentScaleRules(specs)
+			if err == nil {
+				t.Error("Expected an error for ", specs)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+	"github.com/NETWAYS/go-check/result"
+)
+
+// Serves the sensors of the devices as Prometheus metrics, every scrape queries the device
+type exporter struct {
+	config *Config
+
+	// Failed scrapes per target, only targets which are known to be devices are counted
+	// (the one given by --host and those which answered a scrape), the target parameter
+	// could otherwise add an entry for every scrape
+	mutex  sync.Mutex
+	errors map[string]uint64
+}
+
+func (c *Config) serve() error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", newExporter(c))
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "check_akcp_sensorprobeXplus exporter, the metrics are served on /metrics\n")
+	})
+
+	server := &http.Server{
+		Addr:              c.listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	c.logVerbose("Listening on %s", c.listen)
+
+	return server.ListenAndServe()
+}
+
+func newExporter(c *Config) *exporter {
+	e := &exporter{
+		config: c,
+		errors: make(map[string]uint64),
+	}
+
+	if c.hostname != "" {
+		e.errors[c.hostname] = 0
+	}
+
+	return e
+}
+
+func (e *exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		target = e.config.hostname
+	}
+
+	c := e.config.forHost(target)
+	c.mode = "queryAllSensors"
+	c.record = ""
+
+	// Prometheus announces its timeout, the time of a check is used otherwise
+	timeout := c.timeout
+	if seconds, err := strconv.ParseFloat(r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"), 64); err == nil && seconds > 0 {
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout-min(timeout/10, time.Second))
+	defer cancel()
+
+	start := time.Now()
+	err := e.scrape(ctx, c)
+	duration := time.Since(start)
+
+	if err != nil {
+		c.logVerbose("Scraping %s failed: %s", target, err)
+	}
+
+	errorCount := e.countScrape(target, err)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	m := metricWriter{w: w}
+
+	up := 1.0
+	if err != nil {
+		up = 0
+	}
+
+	m.family("akcp_up", "gauge", "Whether the device could be queried")
+	m.sample("akcp_up", nil, up)
+
+	m.family("akcp_scrape_duration_seconds", "gauge", "Duration of the queries to the device")
+	m.sample("akcp_scrape_duration_seconds", nil, duration.Seconds())
+
+	m.family("akcp_scrape_errors_total", "counter", "Number of failed scrapes of the device since the start of the exporter")
+	m.sample("akcp_scrape_errors_total", nil, float64(errorCount))
+
+	if err == nil && c.report.Device != nil {
+		writeDeviceMetrics(&m, c.report, akcp.GetDeviceTypeName(c.deviceType))
+	}
+}
+
+// Returns the number of failed scrapes of the target including this one,
+// always 0 for a target which never answered (it may not be a device at all)
+func (e *exporter) countScrape(target string, err error) uint64 {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	count, known := e.errors[target]
+
+	if err != nil && known {
+		count++
+	}
+
+	if err == nil || known {
+		e.errors[target] = count
+	}
+
+	return count
+}
+
+// An incomplete scrape is reported as failed
+func (e *exporter) scrape(ctx context.Context, c *Config) error {
+	params, closeClient, err := c.openClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	var overall result.Overall
+
+	return c.queryDevice(akcp.NewContextClient(ctx, params), &overall)
+}
+
+var sensorStatuses = []akcp.SensorStatus{
+	akcp.NoStatus,
+	akcp.Normal,
+	akcp.HighWarning,
+	akcp.HighCritical,
+	akcp.LowWarning,
+	akcp.LowCritical,
+	akcp.SensorError,
+}
+
+func writeDeviceMetrics(m *metricWriter, report checkReport, deviceType string) {
+	m.family("akcp_device_info", "gauge", "Identity of the device")
+	m.sample("akcp_device_info", []string{
+		"name", report.Device.Name,
+		"location", report.Device.Location,
+		"type", report.Device.Type,
+		"device", deviceType,
+	}, 1)
+
+	m.family("akcp_sensor_value", "gauge", "Current value of the sensor")
+
+	for _, sensor := range report.Sensors {
+		if sensor.Error != "" {
+			// There is no value, which could be trusted
+			continue
+		}
+
+		m.sample("akcp_sensor_value", []string{
+			"index", sensor.Index,
+			"name", sensor.Name,
+			"type", sensor.Type,
+			"unit", sensor.Unit,
+		}, sensor.Value)
+	}
+
+	m.family("akcp_sensor_status", "gauge", "Status of the sensor as reported by the device, 1 for the current status")
+
+	for _, sensor := range report.Sensors {
+		if sensor.Error != "" {
+			continue
+		}
+
+		for _, status := range sensorStatuses {
+			value := 0.0
+			if sensor.Status == status.String() {
+				value = 1
+			}
+
+			m.sample("akcp_sensor_status", []string{
+				"index", sensor.Index,
+				"name", sensor.Name,
+				"status", status.String(),
+			}, value)
+		}
+	}
+}
+
+// Writes the Prometheus text exposition format
+type metricWriter struct {
+	w io.Writer
+}
+
+func (m *metricWriter) family(name, metricType, help string) {
+	fmt.Fprintf(m.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// Writes a single sample, labels are given as pairs of name and value
+func (m *metricWriter) sample(name string, labels []string, value float64) {
+	var line strings.Builder
+
+	line.WriteString(name)
+
+	if len(labels) > 0 {
+		pairs := make([]string, 0, len(labels)/2)
+		for i := 0; i+1 < len(labels); i += 2 {
+			pairs = append(pairs, labels[i]+`="`+escapeLabelValue(labels[i+1])+`"`)
+		}
+
+		line.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+
+	line.WriteString(" " + strconv.FormatFloat(value, 'g', -1, 64) + "\n")
+
+	_, _ = io.WriteString(m.w, line.String())
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
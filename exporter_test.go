@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExporter(t *testing.T) {
+	testcases := map[string]struct {
+		fromWalk string
+		target   string
+		expected []string
+	}{
+		"sensorProbePlus": {
+			fromWalk: "testdata/sensorProbePlus.walk",
+			expected: []string{
+				"akcp_up 1\n",
+				`akcp_device_info{name="SPX+ Demo",location="Room 217",type="SPX+ F7 1.0.5233 May 12 2020 09:41:",device="sensorProbe+"} 1`,
+				`akcp_sensor_value{index="1.1.1.1",name="Temperature Port 1",type="temperature",unit="C"} 27.1`,
+				`akcp_sensor_status{index="1.2.1.1",name="Dual Humidity Port 2",status="lowWarning"} 1`,
+				`akcp_sensor_status{index="1.2.1.1",name="Dual Humidity Port 2",status="normal"} 0`,
+				"akcp_scrape_errors_total 0\n",
+			},
+		},
+		"sensorProbe": {
+			fromWalk: "testdata/sensorProbe.walk",
+			expected: []string{
+				`akcp_sensor_value{index="16.0",name="Freezer",type="temperature",unit="C"} -22`,
+			},
+		},
+		"missingCapture": {
+			fromWalk: "testdata/missing.walk",
+			expected: []string{
+				"akcp_up 0\n",
+				"akcp_scrape_errors_total 1\n",
+			},
+		},
+		// Only the errors of targets known to be devices are counted
+		"missingCaptureUnknownTarget": {
+			fromWalk: "testdata/missing.walk",
+			target:   "unknown",
+			expected: []string{
+				"akcp_up 0\n",
+				"akcp_scrape_errors_total 0\n",
+			},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			config := newTestConfig(t, Config{fromWalk: tc.fromWalk, hostname: "probe", mode: "serve"})
+
+			target := tc.target
+			if target == "" {
+				target = config.hostname
+			}
+
+			recorder := httptest.NewRecorder()
+			newExporter(config).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics?target="+target, nil))
+
+			actual := recorder.Body.String()
+
+			for _, expected := range tc.expected {
+				if !strings.Contains(actual, expected) {
+					t.Error("\nActual: ", actual, "\nExpected: ", expected)
+				}
+			}
+		})
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	actual := escapeLabelValue("Rack \"A\"\\1\nfront")
+	expected := `Rack \"A\"\\1\nfront`
+
+	if actual != expected {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+}
+
+func TestCountScrape(t *testing.T) {
+	e := newExporter(&Config{hostname: "probe"})
+	failed := errors.New("no answer")
+
+	scrapes := []struct {
+		target   string
+		err      error
+		expected uint64
+	}{
+		{target: "probe", err: failed, expected: 1},
+		{target: "other", err: failed, expected: 0},
+		{target: "other", expected: 0},
+		{target: "other", err: failed, expected: 1},
+		{target: "probe", err: failed, expected: 2},
+	}
+
+	for _, scrape := range scrapes {
+		actual := e.countScrape(scrape.target, scrape.err)
+		if actual != scrape.expected {
+			t.Error("\nActual: ", actual, "\nExpected: ", scrape.expected, " for ", scrape.target)
+		}
+	}
+
+	if len(e.errors) != 2 {
+		t.Error("\nActual: ", len(e.errors), "\nExpected: ", 2)
+	}
+}
+
+// Overlapping scrapes resolve the sensor types of the rules each on their own, run with -race
+func TestExporterConcurrentScrapes(t *testing.T) {
+	config := newTestConfig(t, Config{
+		fromWalk:          "testdata/sensorProbePlusContacts.walk",
+		hostname:          "probe",
+		mode:              "serve",
+		contactStateParam: []string{"dry_inout=open"},
+		currentScaleParam: []string{"four_20mA=0:500:Pa"},
+	})
+
+	e := newExporter(config)
+
+	var wg sync.WaitGroup
+
+	recorders := make([]*httptest.ResponseRecorder, 4)
+
+	for i := range recorders {
+		recorders[i] = httptest.NewRecorder()
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			e.ServeHTTP(recorders[i], httptest.NewRequest("GET", "/metrics?target=probe", nil))
+		}()
+	}
+
+	wg.Wait()
+
+	expected := `akcp_sensor_status{index="1.1.1.1",name="Server Room Door",status="highCritical"} 1`
+
+	for _, recorder := range recorders {
+		if !strings.Contains(recorder.Body.String(), expected) {
+			t.Error("\nActual: ", recorder.Body.String(), "\nExpected: ", expected)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+)
+
+// Selects sensors by name and by their position on the device, in addition to the
+// exclusion by sensor type
+type sensorFilter struct {
+	includeNames []*regexp.Regexp
+	excludeNames []*regexp.Regexp
+	includePorts []portSelector
+	excludePorts []portSelector
+}
+
+// A sensor index, a port ("port" or "port.subport" as for --sensorPort) or a range of ports
+//
+// Format: <index>, <port>[.<subport>] or <first port>-<last port>
+type portSelector struct {
+	port    string
+	isRange bool
+	low     uint64
+	high    uint64
+}
+
+func parseSensorFilter(includeNames, excludeNames, includePorts, excludePorts []string) (sensorFilter, error) {
+	var (
+		filter sensorFilter
+		err    error
+	)
+
+	filter.includeNames, err = parseNamePatterns(includeNames)
+	if err != nil {
+		return filter, err
+	}
+
+	filter.excludeNames, err = parseNamePatterns(excludeNames)
+	if err != nil {
+		return filter, err
+	}
+
+	filter.includePorts, err = parsePortSelectors(includePorts)
+	if err != nil {
+		return filter, err
+	}
+
+	filter.excludePorts, err = parsePortSelectors(excludePorts)
+	if err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+func (f sensorFilter) clone() sensorFilter {
+	return sensorFilter{
+		includeNames: slices.Clone(f.includeNames),
+		excludeNames: slices.Clone(f.excludeNames),
+		includePorts: slices.Clone(f.includePorts),
+		excludePorts: slices.Clone(f.excludePorts),
+	}
+}
+
+func parseNamePatterns(specs []string) ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(specs))
+
+	for _, spec := range specs {
+		pattern, err := regexp.Compile(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sensor name pattern %s: %w", spec, err)
+		}
+
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns, nil
+}
+
+func parsePortSelectors(specs []string) ([]portSelector, error) {
+	selectors := make([]portSelector, 0, len(specs))
+
+	for _, spec := range specs {
+		low, high, isRange := strings.Cut(spec, "-")
+		if !isRange {
+			if spec == "" {
+				return nil, errors.New("empty sensor port")
+			}
+
+			selectors = append(selectors, portSelector{port: spec})
+
+			continue
+		}
+
+		selector := portSelector{isRange: true}
+
+		var err error
+
+		selector.low, err = strconv.ParseUint(low, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sensor port range %s: %w", spec, err)
+		}
+
+		selector.high, err = strconv.ParseUint(high, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sensor port range %s: %w", spec, err)
+		}
+
+		if selector.low > selector.high {
+			return nil, fmt.Errorf("invalid sensor port range %s: first port is greater than the last", spec)
+		}
+
+		selectors = append(selectors, selector)
+	}
+
+	return selectors, nil
+}
+
+func (s portSelector) matches(sensorIndex string) bool {
+	if !s.isRange {
+		return akcp.SensorIndexMatchesPort(sensorIndex, s.port)
+	}
+
+	// The port is the second part of the index on all devices
+	// (board.port.subport.sensor on the sensorProbe+, table.port on the others)
+	parts := strings.Split(sensorIndex, ".")
+	if len(parts) < 2 {
+		return false
+	}
+
+	port, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return false
+	}
+
+	return port >= s.low && port <= s.high
+}
+
+// Whether the sensor should be checked, a sensor has to match one of the included names
+// and ports (if any are given) and none of the excluded ones
+func (f sensorFilter) matches(sensor akcp.SensorDetails) bool {
+	if len(f.includeNames) > 0 && !matchesAnyName(f.includeNames, sensor.Name) {
+		return false
+	}
+
+	if len(f.includePorts) > 0 && !matchesAnyPort(f.includePorts, sensor.Index) {
+		return false
+	}
+
+	return !matchesAnyName(f.excludeNames, sensor.Name) && !matchesAnyPort(f.excludePorts, sensor.Index)
+}
+
+func matchesAnyName(patterns []*regexp.Regexp, name string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesAnyPort(selectors []portSelector, sensorIndex string) bool {
+	for _, selector := range selectors {
+		if selector.matches(sensorIndex) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// How specific a rule given by the user is, the most specific matching rule is used
+const (
+	scopeAll = iota
+	scopeSensorType
+	scopeSensorPort
+	scopeSensorName
+)
+
+// Limits a rule given by the user (e.g. a threshold or the state of a contact) to a sensor type,
+// to the sensors at a port or with a matching name, a rule without a selector applies to all sensors.
+// A selector starting with a digit is a port (as for --include-port), everything else
+// is the name of a sensor type of the device, e.g. "3=..." is port 3 and never a sensor type.
+//
+// Format: [<sensor type>=]<rule>, <port>=<rule> or /<regular expression>/=<rule>
+type sensorSelector struct {
+	scope       int
+	typeName    string
+	sensorType  uint64
+	namePattern *regexp.Regexp
+	port        portSelector
+}
+
+// Splits the selector off the rule, the rest of the rule is returned
+func parseSensorSelector(spec string) (sensorSelector, string, error) {
+	selector := sensorSelector{scope: scopeAll}
+
+	idx := strings.LastIndex(spec, "=")
+	if idx < 0 {
+		return selector, spec, nil
+	}
+
+	scope := spec[:idx]
+
+	switch {
+	case len(scope) >= 2 && strings.HasPrefix(scope, "/") && strings.HasSuffix(scope, "/"):
+		pattern, err := regexp.Compile(scope[1 : len(scope)-1])
+		if err != nil {
+			return selector, "", fmt.Errorf("invalid sensor name pattern in %s: %w", spec, err)
+		}
+
+		selector.scope = scopeSensorName
+		selector.namePattern = pattern
+	case scope != "" && scope[0] >= '0' && scope[0] <= '9':
+		ports, err := parsePortSelectors([]string{scope})
+		if err != nil {
+			return selector, "", fmt.Errorf("invalid port in %s: %w", spec, err)
+		}
+
+		selector.scope = scopeSensorPort
+		selector.port = ports[0]
+	case scope != "":
+		selector.scope = scopeSensorType
+		selector.typeName = scope
+	}
+
+	return selector, spec[idx+1:], nil
+}
+
+// Translates the name of the sensor type into the sensor type of the device
+func (s *sensorSelector) resolveSensorType(deviceType int) error {
+	if s.scope != scopeSensorType {
+		return nil
+	}
+
+	val, err := akcp.GetSensorTypeInt(s.typeName, deviceType)
+	if err != nil {
+		return errors.New("invalid sensor type: " + s.typeName)
+	}
+
+	s.sensorType = uint64(val)
+
+	return nil
+}
+
+// Returns a copy of the rules with the sensor types of the device, see resolveSensorType.
+// The rules given by the user are shared by all hosts, which may be different devices.
+func resolveSensorTypes[S ~[]R, R any, P interface {
+	*R
+	resolveSensorType(deviceType int) error
+}](rules S, deviceType int) (S, error) {
+	resolved := slices.Clone(rules)
+
+	for i := range resolved {
+		err := P(&resolved[i]).resolveSensorType(deviceType)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resolved, nil
+}
+
+// Returns how specific the selector is, if it matches the sensor
+func (s sensorSelector) match(sensor akcp.SensorDetails) (int, bool) {
+	switch s.scope {
+	case scopeSensorType:
+		return s.scope, s.sensorType == sensor.SensorType
+	case scopeSensorPort:
+		return s.scope, s.port.matches(sensor.Index)
+	case scopeSensorName:
+		return s.scope, s.namePattern.MatchString(sensor.Name)
+	default:
+		return s.scope, true
+	}
+}
+
+// Returns the most specific rule for the sensor, later rules win over earlier
+// ones of the same specificity
+func lookupRule[R interface {
+	match(sensor akcp.SensorDetails) (int, bool)
+}](rules []R, sensor akcp.SensorDetails) (R, bool) {
+	var (
+		best      R
+		bestScope int
+		found     bool
+	)
+
+	for _, rule := range rules {
+		scope, ok := rule.match(sensor)
+		if ok && (!found || scope >= bestScope) {
+			best, bestScope, found = rule, scope, true
+		}
+	}
+
+	return best, found
+}
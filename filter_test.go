@@ -0,0 +1,168 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+)
+
+func TestSensorFilter(t *testing.T) {
+	testSensor := akcp.SensorDetails{Index: "1.2.1.1", Name: "Temp Sensor Test"}
+	rackSensor := akcp.SensorDetails{Index: "1.5.1.1", Name: "Rack Temperature"}
+	classicSensor := akcp.SensorDetails{Index: "16.3", Name: "Freezer"}
+
+	testcases := map[string]struct {
+		includeNames []string
+		excludeNames []string
+		includePorts []string
+		excludePorts []string
+		sensor       akcp.SensorDetails
+		expected     bool
+	}{
+		"noFilter": {
+			sensor:   testSensor,
+			expected: true,
+		},
+		"excludeName": {
+			excludeNames: []string{"Test$"},
+			sensor:       testSensor,
+			expected:     false,
+		},
+		"excludeNameOther": {
+			excludeNames: []string{"Test$"},
+			sensor:       rackSensor,
+			expected:     true,
+		},
+		"includeName": {
+			includeNames: []string{"^Rack"},
+			sensor:       testSensor,
+			expected:     false,
+		},
+		"includePortRange": {
+			includePorts: []string{"4-6"},
+			sensor:       rackSensor,
+			expected:     true,
+		},
+		"includePortRangeOutside": {
+			includePorts: []string{"4-6"},
+			sensor:       testSensor,
+			expected:     false,
+		},
+		"excludePort": {
+			excludePorts: []string{"2.1"},
+			sensor:       testSensor,
+			expected:     false,
+		},
+		"excludeIndex": {
+			excludePorts: []string{"1.5.1.1"},
+			sensor:       rackSensor,
+			expected:     false,
+		},
+		"classicPortRange": {
+			includePorts: []string{"2-3"},
+			sensor:       classicSensor,
+			expected:     true,
+		},
+		"includeNameAndPort": {
+			includeNames: []string{"Temp"},
+			includePorts: []string{"5"},
+			sensor:       testSensor,
+			expected:     false,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			filter, err := parseSensorFilter(tc.includeNames, tc.excludeNames, tc.includePorts, tc.excludePorts)
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+
+			actual := filter.matches(tc.sensor)
+
+			if actual != tc.expected {
+				t.Error("\nActual: ", actual, "\nExpected: ", tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseSensorFilterErrors(t *testing.T) {
+	testcases := map[string]struct {
+		names []string
+		ports []string
+	}{
+		"invalidPattern":  {names: []string{"(unclosed"}},
+		"invalidRange":    {ports: []string{"1-a"}},
+		"descendingRange": {ports: []string{"4-1"}},
+		"emptyPort":       {ports: []string{""}},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseSensorFilter(tc.names, nil, tc.ports, nil)
+			if err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestParseSensorSelector(t *testing.T) {
+	testcases := map[string]struct {
+		spec          string
+		expectedScope int
+		expectedRule  string
+	}{
+		"all":         {spec: "18:27", expectedScope: scopeAll, expectedRule: "18:27"},
+		"sensorType":  {spec: "temperature=18:27", expectedScope: scopeSensorType, expectedRule: "18:27"},
+		"port":        {spec: "3=open", expectedScope: scopeSensorPort, expectedRule: "open"},
+		"sensorIndex": {spec: "1.3.1.1=open", expectedScope: scopeSensorPort, expectedRule: "open"},
+		"portRange":   {spec: "1-4=15:30", expectedScope: scopeSensorPort, expectedRule: "15:30"},
+		"numericName": {spec: "/^3$/=open", expectedScope: scopeSensorName, expectedRule: "open"},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			selector, rule, err := parseSensorSelector(tc.spec)
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+
+			if selector.scope != tc.expectedScope || rule != tc.expectedRule {
+				t.Error("\nActual: ", selector.scope, rule, "\nExpected: ", tc.expectedScope, tc.expectedRule)
+			}
+		})
+	}
+}
+
+func TestResolveSensorTypes(t *testing.T) {
+	testcases := map[string]struct {
+		specs       []string
+		deviceType  int
+		expectedErr bool
+	}{
+		"sensorProbePlus": {specs: []string{"temperature=closed", "3=closed"}, deviceType: akcp.SensorProbePlusType},
+		"sensorProbe":     {specs: []string{"dry_contact=closed"}, deviceType: akcp.SensorProbeType},
+		"unknownType":     {specs: []string{"dry_contact=closed"}, deviceType: akcp.SensorProbePlusType, expectedErr: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			rules, err := parseContactRules(tc.specs)
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+
+			_, err = resolveSensorTypes(rules, tc.deviceType)
+			if (err != nil) != tc.expectedErr {
+				t.Error("\nActual: ", err, "\nExpected error: ", tc.expectedErr)
+			}
+
+			// The rules are shared by all hosts, which may be different devices
+			if rules[0].sensorType != 0 {
+				t.Error("\nActual: ", rules[0].sensorType, "\nExpected: ", 0)
+			}
+		})
+	}
+}
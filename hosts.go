@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+	"github.com/NETWAYS/go-check"
+	"github.com/NETWAYS/go-check/result"
+)
+
+// The outcome of the check of one of several hosts
+type hostResult struct {
+	host    string
+	config  *Config
+	overall result.Overall
+	err     error
+}
+
+// Collects the hosts given by --host and --hosts-file
+func (c *Config) validateHosts() error {
+	c.hosts = slices.Clone(c.hostsParam)
+
+	if c.hostsFile != "" {
+		hosts, err := readHostsFile(c.hostsFile)
+		if err != nil {
+			return err
+		}
+
+		c.hosts = append(c.hosts, hosts...)
+	}
+
+	if len(c.hosts) == 1 {
+		c.hostname = c.hosts[0]
+	}
+
+	if len(c.hosts) <= 1 {
+		return nil
+	}
+
+	if val, ok := modes[c.mode]; ok && (val == listPossibleSensors || val == serve) {
+		return fmt.Errorf("mode %s can only be used with a single host", c.mode)
+	}
+
+	if c.record != "" {
+		return errors.New("--record can only be used with a single host")
+	}
+
+	return nil
+}
+
+// The name of the device as given by its identity, the host if it has none
+func (r hostResult) deviceName() string {
+	if r.err != nil || r.config.report.Device == nil || r.config.report.Device.Name == "" {
+		return r.host
+	}
+
+	return r.config.report.Device.Name
+}
+
+// Reads one host per line, empty lines and comments (#) are ignored
+func readHostsFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var hosts []string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		hosts = append(hosts, line)
+	}
+
+	return hosts, scanner.Err()
+}
+
+// Returns a copy of the configuration to query a single host, so concurrent queries
+// do not share any state. Every slice the query may change has to be cloned.
+func (c *Config) forHost(host string) *Config {
+	tmp := *c
+	tmp.hosts = nil
+	tmp.report = checkReport{}
+	tmp.requests = nil
+	tmp.timedOut = false
+	tmp.warning = slices.Clone(c.warning)
+	tmp.critical = slices.Clone(c.critical)
+	tmp.contactStates = slices.Clone(c.contactStates)
+	tmp.currentScales = slices.Clone(c.currentScales)
+	tmp.excludeSensorType = slices.Clone(c.excludeSensorType)
+	tmp.excludeSensorTypeInteger = slices.Clone(c.excludeSensorTypeInteger)
+	tmp.filter = c.filter.clone()
+
+	if host != "" {
+		tmp.hostname = host
+	}
+
+	return &tmp
+}
+
+// Checks all hosts concurrently and combines their results
+func (c *Config) runHosts(ctx context.Context, overall *result.Overall) error {
+	results := make([]hostResult, len(c.hosts))
+
+	var wg sync.WaitGroup
+
+	for i, host := range c.hosts {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			results[i].host = host
+			results[i].config = c.forHost(host)
+			results[i].err = results[i].config.check(ctx, &results[i].overall)
+		}()
+	}
+
+	wg.Wait()
+
+	c.combineHostResults(results, overall)
+
+	return nil
+}
+
+// Adds the subchecks of every host, prefixed with the name of the device, in the order of the hosts
+// Hosts which could not be checked are reported as UNKNOWN
+func (c *Config) combineHostResults(results []hostResult, overall *result.Overall) {
+	failed := 0
+
+	for i := range results {
+		// The partial result of a device which did not even tell its identity holds nothing but the timeout
+		if results[i].err == nil && results[i].config.timedOut && results[i].config.report.Device == nil {
+			results[i].err = fmt.Errorf("%w, the device did not answer within %s", akcp.ErrDeadline, c.timeout)
+		}
+	}
+
+	names := make(map[string]int)
+	for _, hostResult := range results {
+		names[hostResult.deviceName()]++
+	}
+
+	for _, hostResult := range results {
+		if hostResult.err != nil {
+			failed++
+
+			// The hint is kept, but a device which can not be queried says nothing about its sensors
+			_, explained := c.explainError(hostResult.err)
+
+			sc := result.PartialResult{}
+			_ = sc.SetDefaultState(check.Unknown)
+			_ = sc.SetState(check.Unknown)
+			sc.Output = fmt.Sprintf("%s: %s", hostResult.host, explained)
+			overall.AddSubcheck(sc)
+
+			c.report.Devices = append(c.report.Devices, deviceReport{Host: hostResult.host, Error: hostResult.err.Error()})
+
+			continue
+		}
+
+		name := hostResult.deviceName()
+		if names[name] > 1 {
+			// Devices which carry the same name are told apart by their host
+			name = fmt.Sprintf("%s (%s)", name, hostResult.host)
+		}
+
+		if device := hostResult.config.report.Device; device != nil {
+			tmp := *device
+			tmp.Host = hostResult.host
+			c.report.Devices = append(c.report.Devices, tmp)
+		}
+
+		for _, sc := range hostResult.overall.PartialResults {
+			sc.Output = name + ": " + sc.Output
+
+			// The labels have to be unique across all devices
+			for _, pf := range sc.Perfdata {
+				pf.Label = name + "/" + pf.Label
+			}
+
+			overall.AddSubcheck(sc)
+		}
+
+		for _, sensor := range hostResult.config.report.Sensors {
+			sensor.Device = name
+			c.report.Sensors = append(c.report.Sensors, sensor)
+		}
+	}
+
+	overall.Summary = fmt.Sprintf("%d of %d devices answered", len(results)-failed, len(results))
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+	"github.com/NETWAYS/go-check"
+	"github.com/NETWAYS/go-check/result"
+)
+
+func TestCombineHostResults(t *testing.T) {
+	newHostResult := func(host string, deviceName string, sensors ...akcp.SensorDetails) hostResult {
+		hostResult := hostResult{host: host, config: &Config{}}
+		hostResult.config.report.Device = newDeviceReport(akcp.DeviceIdentity{Name: deviceName})
+
+		for _, sensor := range sensors {
+			err := hostResult.config.addSensorResult(sensor, &hostResult.overall)
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+		}
+
+		return hostResult
+	}
+
+	sensor := akcp.SensorDetails{Index: "1.1.1.1", Name: "Temperature", Value: 21, Unit: "C", Status: akcp.Normal}
+
+	results := []hostResult{
+		newHostResult("10.0.0.1", "Rack A", sensor),
+		{host: "10.0.0.2", err: &akcp.Error{Kind: akcp.ErrTimeout, Err: errors.New("request timeout")}},
+		newHostResult("10.0.0.3", "Rack B", sensor),
+		newHostResult("10.0.0.4", "Rack B", sensor),
+		{host: "10.0.0.5", config: &Config{timedOut: true}},
+	}
+
+	addTimeoutResult(10*time.Second, &results[4].overall)
+
+	config := &Config{timeout: 10 * time.Second}
+	overall := &result.Overall{}
+
+	config.combineHostResults(results, overall)
+
+	actual := overall.GetOutput()
+
+	expected := []string{
+		"3 of 5 devices answered",
+		"\\_ [OK] Rack A: Temperature: 21.0℃\n\\_ [UNKNOWN] 10.0.0.2: device did not answer: request timeout",
+		"\\_ [OK] Rack B (10.0.0.3): Temperature",
+		"'Rack A/Temperature'=21C",
+		"'Rack B (10.0.0.4)/Temperature'=21C",
+		"[UNKNOWN] 10.0.0.5: time of the check is up, the device did not answer within 10s",
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(actual, e) {
+			t.Error("\nActual: ", actual, "\nExpected: ", e)
+		}
+	}
+
+	if overall.GetStatus() != check.Unknown {
+		t.Error("\nActual: ", overall.GetStatus(), "\nExpected: ", check.Unknown)
+	}
+
+	if len(config.report.Devices) != 5 || config.report.Devices[1].Error != "device did not answer: request timeout" || len(config.report.Sensors) != 3 {
+		t.Error("\nActual: ", config.report, "\nExpected: 5 devices and 3 sensors")
+	}
+}
+
+func TestValidateHosts(t *testing.T) {
+	hostsFile := filepath.Join(t.TempDir(), "hosts")
+
+	err := os.WriteFile(hostsFile, []byte("# Room 217\n10.0.0.2\n\n  10.0.0.3  \n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := map[string]struct {
+		config        Config
+		expected      []string
+		expectedError bool
+	}{
+		"single": {
+			config:   Config{hostsParam: []string{"10.0.0.1"}, mode: "queryAllSensors"},
+			expected: []string{"10.0.0.1"},
+		},
+		"file": {
+			config:   Config{hostsParam: []string{"10.0.0.1"}, hostsFile: hostsFile, mode: "queryAllSensors"},
+			expected: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+		},
+		"listWithSeveralHosts": {
+			config:        Config{hostsParam: []string{"10.0.0.1", "10.0.0.2"}, mode: "listPossibleSensors"},
+			expectedError: true,
+		},
+		"recordWithSeveralHosts": {
+			config:        Config{hostsParam: []string{"10.0.0.1", "10.0.0.2"}, mode: "queryAllSensors", record: "capture.json"},
+			expectedError: true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			config := tc.config
+
+			err := config.validateHosts()
+			if tc.expectedError {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+
+			if !slices.Equal(config.hosts, tc.expected) {
+				t.Error("\nActual: ", config.hosts, "\nExpected: ", tc.expected)
+			}
+		})
+	}
+}
+
+// The sensor types of the rules are resolved for each host, run with -race
+func TestRunHostsAutoDetect(t *testing.T) {
+	overall := runTestConfig(t, Config{
+		hostsParam:        []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+		fromWalk:          "testdata/sensorProbePlusContacts.walk",
+		mode:              "queryAllSensors",
+		warningParam:      []string{"temperature=18:27"},
+		contactStateParam: []string{"dry_inout=open:warning"},
+		currentScaleParam: []string{"four_20mA=0:500:Pa"},
+	})
+
+	actual := overall.GetOutput()
+
+	expected := []string{
+		"3 of 3 devices answered",
+		"[WARNING] SPX+ Contacts (10.0.0.1): Server Room Door: closed (expected open)",
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(actual, e) {
+			t.Error("\nActual: ", actual, "\nExpected: ", e)
+		}
+	}
+}
@@ -5,28 +5,51 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/securityProbe"
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbe"
 	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
-	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/utils"
 	"github.com/NETWAYS/go-check"
 	"github.com/gosnmp/gosnmp"
 )
 
 // Sensor statuses from the AKCP MIB (probably do not apply to all sensors)
 // noStatus = 1,normal = 2,highWarning = 3,highCritical = 4,lowWarning = 5,lowCritical = 6,sensorError = 7,
-type snsrStts uint64
+type SensorStatus uint64
 
 const (
-	NoStatus     snsrStts = 1
-	Normal       snsrStts = 2
-	HighWarning  snsrStts = 3
-	HighCritical snsrStts = 4
-	LowWarning   snsrStts = 5
-	LowCritical  snsrStts = 6
-	SensorError  snsrStts = 7
+	NoStatus     SensorStatus = 1
+	Normal       SensorStatus = 2
+	HighWarning  SensorStatus = 3
+	HighCritical SensorStatus = 4
+	LowWarning   SensorStatus = 5
+	LowCritical  SensorStatus = 6
+	SensorError  SensorStatus = 7
 )
 
+var sensorStatusNames = map[SensorStatus]string{
+	NoStatus:     "noStatus",
+	Normal:       "normal",
+	HighWarning:  "highWarning",
+	HighCritical: "highCritical",
+	LowWarning:   "lowWarning",
+	LowCritical:  "lowCritical",
+	SensorError:  "sensorError",
+}
+
+// Returns the name of the status as used in the AKCP MIB
+func (s SensorStatus) String() string {
+	name, ok := sensorStatusNames[s]
+	if !ok {
+		return fmt.Sprintf("unknown(%d)", uint64(s))
+	}
+
+	return name
+}
+
 type SensorType uint64
 
 type MayThreshold struct {
@@ -35,25 +58,166 @@ type MayThreshold struct {
 }
 
 type SensorDetails struct {
+	Index        string
 	SensorType   uint64
 	Name         string
 	Value        float64
 	Unit         string
-	Status       snsrStts
+	Status       SensorStatus
 	Acknowledged bool
 	Warning      MayThreshold
 	Critical     MayThreshold
 	Description  string
+	// Dry contacts only
+	Direction   ContactDirection
+	NormalState ContactState
+	// 4-20 mA sensors only
+	LoopRange CurrentLoopRange
+	// Water ropes only, the value is the location of a leak
+	RopeLength float64
+	// Set if the sensor could not be read completely (e.g. a half-connected sensor or an unexpected value type)
+	Err error
+}
+
+// Returns whether the sensor only reports a state (e.g. a contact or a motion detector),
+// its value is not a measurement
+func IsStateOnly(sensorType uint64) bool {
+	switch sensorType {
+	case sensorProbePlus.Motion, sensorProbePlus.Water, sensorProbePlus.Security, sensorProbePlus.Siren,
+		sensorProbePlus.Relay, sensorProbePlus.Smoke:
+		return true
+	default:
+		return IsContact(sensorType) || IsWaterRope(sensorType)
+	}
+}
+
+type DeviceIdentity struct {
+	Name     string
+	Location string
+	Type     string
 }
 
 const akcpBaseOID = ".1.3.6.1.4.1.3854"
 
+// MIB-II system group, used for devices without identity OIDs in the AKCP tree
+const (
+	sysDescrOID    = ".1.3.6.1.2.1.1.1.0"
+	sysObjectIDOID = ".1.3.6.1.2.1.1.2.0"
+	sysNameOID     = ".1.3.6.1.2.1.1.5.0"
+	sysLocationOID = ".1.3.6.1.2.1.1.6.0"
+)
+
 const (
+	AutoDetectType      = 0
 	SensorProbeType     = 1
 	SecurityProbeType   = 2
 	SensorProbePlusType = 3
 )
 
+var deviceTypeNames = map[int]string{
+	SensorProbeType:     "sensorProbe",
+	SecurityProbeType:   "securityProbe",
+	SensorProbePlusType: "sensorProbe+",
+}
+
+func GetDeviceTypeName(deviceType int) string {
+	name, ok := deviceTypeNames[deviceType]
+	if !ok {
+		return fmt.Sprintf("unknown(%d)", deviceType)
+	}
+
+	return name
+}
+
+// Figures out which kind of AKCP device is answering
+// Only the sensorProbe+ has the device type in its own part of the AKCP tree, the classic
+// devices are told apart by their sysObjectID and sysDescr
+func DetectDeviceType(params Client) (int, error) {
+	// The classic devices lack this OID, so it is not asked together with the system group.
+	// Only a missing or unexpected value points to another device, a device which does not answer
+	// or rejects the credentials would fail the next request as well
+	query, err := get(params, []string{akcpBaseOID + sensorProbePlus.DeviceType})
+	if err != nil && !errors.Is(err, ErrNoSuchObject) && !errors.Is(err, ErrNoSuchInstance) {
+		return 0, err
+	}
+
+	if err == nil && query.Variables[0].Type == gosnmp.OctetString {
+		return SensorProbePlusType, nil
+	}
+
+	query, err = get(params, []string{sysObjectIDOID, sysDescrOID})
+	if err != nil {
+		return 0, err
+	}
+
+	objectID := ValueToString(query.Variables[0])
+	description := strings.ToLower(ValueToString(query.Variables[1]))
+
+	switch {
+	case strings.HasPrefix(objectID, akcpBaseOID+sensorProbePlus.PlusSeriesID+"."):
+		return SensorProbePlusType, nil
+	case strings.Contains(description, "securityprobe"):
+		return SecurityProbeType, nil
+	case strings.Contains(description, "sensorprobe"), strings.HasPrefix(objectID, akcpBaseOID+"."):
+		return SensorProbeType, nil
+	default:
+		return 0, &Error{Kind: ErrUnsupportedDevice, Err: fmt.Errorf("sysObjectID %s is not an AKCP probe", objectID)}
+	}
+}
+
+// Fetches everything which may be of interest to diagnose problems with a device,
+// the identity OIDs and the complete AKCP subtree
+func WalkDevice(params Client) error {
+	// Queried separately, SNMPv1 fails the whole request if one of the OIDs does not exist
+	for _, oid := range []string{sysDescrOID, sysObjectIDOID, sysNameOID, sysLocationOID} {
+		_, err := get(params, []string{oid})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := walk(params, akcpBaseOID)
+
+	return err
+}
+
+// Fetches name, location and type of the device
+func QueryDeviceIdentity(params Client, deviceType int) (DeviceIdentity, error) {
+	var identity DeviceIdentity
+
+	var oids []string
+
+	switch deviceType {
+	case SensorProbePlusType:
+		{
+			oids = []string{
+				akcpBaseOID + sensorProbePlus.DeviceName,
+				akcpBaseOID + sensorProbePlus.DeviceLocation,
+				akcpBaseOID + sensorProbePlus.DeviceType,
+			}
+		}
+	case SensorProbeType, SecurityProbeType:
+		{
+			oids = []string{sysNameOID, sysLocationOID, sysDescrOID}
+		}
+	default:
+		{
+			return identity, unsupportedDevice(deviceType)
+		}
+	}
+
+	query, err := get(params, oids)
+	if err != nil {
+		return identity, err
+	}
+
+	identity.Name = ValueToString(query.Variables[0])
+	identity.Location = ValueToString(query.Variables[1])
+	identity.Type = ValueToString(query.Variables[2])
+
+	return identity, nil
+}
+
 func GetSensorTypeInt(typeString string, deviceType int) (uint32, error) {
 	switch deviceType {
 	case SensorProbePlusType:
@@ -69,15 +233,65 @@ func GetSensorTypeInt(typeString string, deviceType int) (uint32, error) {
 
 			return 0, errors.New("value out of range")
 		}
+	case SensorProbeType:
+		{
+			val, ok := sensorProbe.SensorsTypes[typeString]
+			if !ok {
+				return 0, errors.New("sensor type not found for this device")
+			}
+
+			return uint32(sensorProbeToPlusType[val]), nil
+		}
+	case SecurityProbeType:
+		{
+			val, ok := securityProbe.SensorsTypes[typeString]
+			if !ok {
+				return 0, errors.New("sensor type not found for this device")
+			}
+
+			return uint32(securityProbeToPlusType[val]), nil
+		}
 	default:
 		// TODO
-		return 0, errors.New("other devices not yet implemented")
+		return 0, unsupportedDevice(deviceType)
+	}
+}
+
+// Reverse lookup of the name of a sensor type
+func GetSensorTypeName(sensorType uint64, deviceType int) string {
+	switch deviceType {
+	case SensorProbePlusType:
+		{
+			for name, val := range sensorProbePlus.SensorsTypes {
+				if uint64(val) == sensorType {
+					return name
+				}
+			}
+		}
+	case SensorProbeType:
+		{
+			for name, val := range sensorProbe.SensorsTypes {
+				if uint64(sensorProbeToPlusType[val]) == sensorType {
+					return name
+				}
+			}
+		}
+	case SecurityProbeType:
+		{
+			for name, val := range securityProbe.SensorsTypes {
+				if uint64(securityProbeToPlusType[val]) == sensorType {
+					return name
+				}
+			}
+		}
 	}
+
+	return fmt.Sprintf("unknown(%d)", sensorType)
 }
 
 // Fetches the IDs of all sensors
 // This ID consists of four positive integers, separated by dots (aka usable as an OID)
-func QuerySensorList(params *gosnmp.GoSNMP, deviceType int) (sensors []string, err error) {
+func QuerySensorList(params Client, deviceType int) (sensors []string, err error) {
 	var oid string
 
 	switch deviceType {
@@ -85,128 +299,126 @@ func QuerySensorList(params *gosnmp.GoSNMP, deviceType int) (sensors []string, e
 		{
 			oid = akcpBaseOID + sensorProbePlus.SensorIdListBase
 		}
+	case SensorProbeType, SecurityProbeType:
+		{
+			return queryPortSensorList(params, portTablesOf(deviceType))
+		}
 	default:
 		{
-			return nil, errors.New("not yet implemented")
+			return nil, unsupportedDevice(deviceType)
 		}
 	}
 
 	return GetSensorsIDsFromTable(params, oid)
 }
 
-// nolint: gocognit
-func QueryTemperatureTable(snmp *gosnmp.GoSNMP, deviceType int) ([]SensorDetails, error) {
-	var oid string
+// Looks up the index of a single sensor
+// The sensor may be given by its complete index (board.port.subport.sensor or table.port on
+// the classic devices), by "port.subport" or "port" (the first match wins) or by its exact name
+func FindSensor(params Client, port string, deviceType int) (string, error) {
+	sensors, err := QuerySensorList(params, deviceType)
+	if err != nil {
+		return "", err
+	}
 
-	var sensors []SensorDetails
+	for _, sensor := range sensors {
+		if SensorIndexMatchesPort(sensor, port) {
+			return sensor, nil
+		}
+	}
+
+	var oid string
 
 	switch deviceType {
 	case SensorProbePlusType:
 		{
-			oid = akcpBaseOID + sensorProbePlus.TemperatureTable
+			oid = akcpBaseOID + sensorProbePlus.SensorNameBase
+		}
+	case SensorProbeType, SecurityProbeType:
+		{
+			for _, sensor := range sensors {
+				details, err := QuerySensorDetails(params, sensor, deviceType)
+				if err != nil {
+					return "", err
+				}
+
+				if details.Name == port {
+					return sensor, nil
+				}
+			}
+
+			return "", fmt.Errorf("no sensor found at port %s", port)
 		}
 	default:
 		{
-			return nil, errors.New("not yet implemented")
+			return "", unsupportedDevice(deviceType)
 		}
 	}
 
-	tempTable, err := snmp.BulkWalkAll(oid)
+	names, err := walk(params, oid)
 	if err != nil {
-		return sensors, err
+		return "", err
 	}
 
-	foo, err := utils.ParseSnmpTable(&tempTable, 12)
-	if err != nil {
-		return sensors, err
+	for _, name := range names {
+		if ValueToString(name) == port {
+			return strings.TrimPrefix(name.Name, oid+"."), nil
+		}
 	}
 
-	sensors = make([]SensorDetails, len(*foo))
+	return "", fmt.Errorf("no sensor found at port %s", port)
+}
 
-	counter := 0
+// Compares a sensor index (board.port.subport.sensor) with the port given by the user
+func SensorIndexMatchesPort(sensorIndex string, port string) bool {
+	if port == "" {
+		return false
+	}
 
-	for _, row := range *foo {
-		// Every row is a temperature sensor
-		for _, cell := range row {
-			if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorTemperatureDescription+".") { // nolint: gocritic, nestif
-				sensors[counter].Name = ValueToString(cell.Pdu)
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorTemperatureType+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
+	if sensorIndex == port {
+		return true
+	}
 
-				sensors[counter].SensorType = tmp
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorTemperatureDegree+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
+	indexParts := strings.Split(sensorIndex, ".")
+	portParts := strings.Split(port, ".")
 
-				sensors[counter].Value = float64(tmp)
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorTemperatureUnit+".") {
-				sensors[counter].Unit = ValueToString(cell.Pdu)
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorTemperatureLowWarning+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
-				// Thresholds are off by a factor of 10 to fake decimal point numbers
-				sensors[counter].Warning.Val.Lower = float64(tmp) / 10
-				sensors[counter].Warning.Present = true
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorTemperatureHighWarning+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
-				// Thresholds are off by a factor of 10 to fake decimal point numbers
-				sensors[counter].Warning.Val.Upper = float64(tmp) / 10
-				sensors[counter].Warning.Present = true
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorTemperatureLowCritical+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
-				// Thresholds are off by a factor of 10 to fake decimal point numbers
-				sensors[counter].Critical.Val.Lower = float64(tmp) / 10
-				sensors[counter].Critical.Present = true
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorTemperatureHighCritical+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
-				// Thresholds are off by a factor of 10 to fake decimal point numbers
-				sensors[counter].Critical.Val.Upper = float64(tmp) / 10
-				sensors[counter].Critical.Present = true
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorTemperatureAcknowledge+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
+	if len(indexParts) < 2 || len(portParts) > len(indexParts)-1 {
+		return false
+	}
 
-				if tmp == 1 {
-					sensors[counter].Acknowledged = true
-				} else {
-					sensors[counter].Acknowledged = false
-				}
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorTemperatureStatus+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
+	for i, part := range portParts {
+		if indexParts[i+1] != part {
+			return false
+		}
+	}
 
-				sensors[counter].Status = snsrStts(tmp)
-			}
+	return true
+}
+
+func QueryTemperatureTable(snmp Client, deviceType int) ([]SensorDetails, error) {
+	switch deviceType {
+	case SensorProbePlusType:
+		{
+			return ReadTable(snmp, plusTemperatureTable)
 		}
+	case SensorProbeType, SecurityProbeType:
+		{
+			table, err := portTableByType(portTablesOf(deviceType), sensorProbePlus.Temperature)
+			if err != nil {
+				return nil, err
+			}
 
-		counter++
+			return queryPortTable(snmp, table)
+		}
+	default:
+		{
+			return nil, unsupportedDevice(deviceType)
+		}
 	}
-
-	return sensors, nil
 }
 
 // Fetches the IDs of all temperature sensors in the temperature table
-func GetIDsFromTemperatureTable(params *gosnmp.GoSNMP, deviceType int) (sensors []string, err error) {
+func GetIDsFromTemperatureTable(params Client, deviceType int) (sensors []string, err error) {
 	var oid string
 
 	switch deviceType {
@@ -216,7 +428,7 @@ func GetIDsFromTemperatureTable(params *gosnmp.GoSNMP, deviceType int) (sensors
 		}
 	default:
 		{
-			return nil, errors.New("not yet implemented")
+			return nil, unsupportedDevice(deviceType)
 		}
 	}
 
@@ -225,8 +437,8 @@ func GetIDsFromTemperatureTable(params *gosnmp.GoSNMP, deviceType int) (sensors
 	return sensors, err
 }
 
-func GetSensorsIDsFromTable(params *gosnmp.GoSNMP, tableOID string) (sensors []string, err error) {
-	results, err := params.BulkWalkAll(tableOID)
+func GetSensorsIDsFromTable(params Client, tableOID string) (sensors []string, err error) {
+	results, err := walk(params, tableOID)
 	if err != nil {
 		return nil, err
 	}
@@ -241,7 +453,7 @@ func GetSensorsIDsFromTable(params *gosnmp.GoSNMP, tableOID string) (sensors []s
 // Fetches the IDs of all humidity sensors
 // This ID consists of four positive integers, separated by dots (aka usable as an OID)
 
-func GetIDsFromHumidityTable(params *gosnmp.GoSNMP, deviceType int) (sensors []string, err error) {
+func GetIDsFromHumidityTable(params Client, deviceType int) (sensors []string, err error) {
 	var oid string
 
 	switch deviceType {
@@ -251,7 +463,7 @@ func GetIDsFromHumidityTable(params *gosnmp.GoSNMP, deviceType int) (sensors []s
 		}
 	default:
 		{
-			return nil, errors.New("not yet implemented")
+			return nil, unsupportedDevice(deviceType)
 		}
 	}
 
@@ -260,189 +472,154 @@ func GetIDsFromHumidityTable(params *gosnmp.GoSNMP, deviceType int) (sensors []s
 	return sensors, err
 }
 
-// nolint: gocognit
-func QueryHumidityTable(snmp *gosnmp.GoSNMP, deviceType int) ([]SensorDetails, error) {
-	var oid string
-
-	var sensors []SensorDetails
-
+func QueryHumidityTable(snmp Client, deviceType int) ([]SensorDetails, error) {
 	switch deviceType {
 	case SensorProbePlusType:
 		{
-			oid = akcpBaseOID + sensorProbePlus.HumidityTable
+			return ReadTable(snmp, plusHumidityTable)
+		}
+	case SensorProbeType, SecurityProbeType:
+		{
+			table, err := portTableByType(portTablesOf(deviceType), sensorProbePlus.Humidity_dual)
+			if err != nil {
+				return nil, err
+			}
+
+			return queryPortTable(snmp, table)
 		}
 	default:
 		{
-			return nil, errors.New("not yet implemented")
+			return nil, unsupportedDevice(deviceType)
 		}
 	}
+}
 
-	tempTable, err := snmp.BulkWalkAll(oid)
-	if err != nil {
-		return sensors, err
-	}
-
-	foo, err := utils.ParseSnmpTable(&tempTable, 12)
-	if err != nil {
-		return sensors, err
-	}
-
-	sensors = make([]SensorDetails, len(*foo))
-
-	counter := 0
-
-	for _, row := range *foo {
-		// Every row is a temperature sensor
-		for _, cell := range row {
-			if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorHumidityDescription+".") { // nolint: gocritic,nestif
-				sensors[counter].Name = ValueToString(cell.Pdu)
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorHumidityType+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
-
-				sensors[counter].SensorType = tmp
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorHumidityPercent+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
-
-				sensors[counter].Value = float64(tmp)
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorHumidityUnit+".") {
-				sensors[counter].Unit = ValueToString(cell.Pdu)
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorHumidityLowWarning+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
-
-				sensors[counter].Warning.Val.Lower = float64(tmp)
-				sensors[counter].Warning.Present = true
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorHumidityHighWarning+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
-
-				sensors[counter].Warning.Val.Upper = float64(tmp)
-				sensors[counter].Warning.Present = true
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorHumidityLowCritical+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
-
-				sensors[counter].Critical.Val.Lower = float64(tmp)
-				sensors[counter].Critical.Present = true
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorHumidityHighCritical+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
-
-				sensors[counter].Critical.Val.Upper = float64(tmp)
-				sensors[counter].Critical.Present = true
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorHumidityAcknowledge+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
+func QuerySensorDetails(params Client, sensorIndex string, deviceType int) (SensorDetails, error) {
+	switch deviceType {
+	case SensorProbePlusType:
+		{
+			return ReadTableRow(params, plusSensorTable, sensorIndex)
+		}
+	case SensorProbeType, SecurityProbeType:
+		{
+			table, port, err := findPortTable(portTablesOf(deviceType), sensorIndex)
+			if err != nil {
+				return SensorDetails{}, err
+			}
 
-				if tmp == 1 {
-					sensors[counter].Acknowledged = true
-				} else {
-					sensors[counter].Acknowledged = false
-				}
-			} else if strings.HasPrefix(cell.Pdu.Name, akcpBaseOID+sensorProbePlus.SensorHumidityStatus+".") {
-				tmp, err := ValueToUint64(cell.Pdu)
-				if err != nil {
-					return sensors, err
-				}
+			details, err := ReadTableRow(params, table.Table, port)
+			details.Index = sensorIndex
 
-				sensors[counter].Status = snsrStts(tmp)
-			}
+			return details, err
+		}
+	default:
+		{
+			return SensorDetails{}, unsupportedDevice(deviceType)
 		}
-
-		counter++
 	}
-
-	return sensors, nil
 }
 
-func QuerySensorDetails(params *gosnmp.GoSNMP, sensorIndex string, deviceType int) (SensorDetails, error) {
-	var details SensorDetails
-
-	var tmpOID string
-
-	var oids = make([]string, 8)
-
+// Fetches the details of all sensors of the device
+// Instead of querying every sensor on its own, the columns of the sensor tables are walked once
+func QueryAllSensorDetails(params Client, deviceType int) ([]SensorDetails, error) {
 	switch deviceType {
 	case SensorProbePlusType:
 		{
-			tmpOID = akcpBaseOID
-			oids[0] = tmpOID + sensorProbePlus.SensorNameBase + "." + sensorIndex
-			oids[1] = tmpOID + sensorProbePlus.SensorTypeBase + "." + sensorIndex
-			oids[2] = tmpOID + sensorProbePlus.SensorValueBase + "." + sensorIndex
-			oids[3] = tmpOID + sensorProbePlus.SensorUnitBase + "." + sensorIndex
-			oids[4] = tmpOID + sensorProbePlus.SensorStatusBase + "." + sensorIndex
-			// common on description
-			oids[5] = tmpOID + sensorProbePlus.SensorsOnDescriptionBase + "." + sensorIndex
-			// common off description
-			oids[6] = tmpOID + sensorProbePlus.SensorsOffDescriptionBase + "." + sensorIndex
-			oids[7] = tmpOID + sensorProbePlus.SensorsValueFormatFloatBase + "." + sensorIndex
+			return ReadTableColumns(params, plusSensorTable)
+		}
+	case SensorProbeType, SecurityProbeType:
+		{
+			tables := portTablesOf(deviceType)
+			results := make([][]SensorDetails, len(tables))
+
+			err := forEachConcurrently(len(tables), concurrency(params), func(i int) error {
+				var err error
+
+				results[i], err = queryPortTable(params, tables[i])
+
+				return err
+			})
+			if err != nil && !errors.Is(err, ErrDeadline) {
+				return nil, err
+			}
+
+			// Once the time is up, the sensors of the tables read so far are returned with the error
+			var sensors []SensorDetails
+			for _, tmp := range results {
+				sensors = append(sensors, tmp...)
+			}
+
+			return sensors, err
 		}
 	default:
 		{
-			return details, errors.New("not yet implemented")
+			return nil, unsupportedDevice(deviceType)
 		}
 	}
+}
 
-	query, err := params.Get(oids)
-	if err != nil {
-		return details, err
+// The common sensor table of the sensorProbe+ lacks some details of the sensors (e.g. thresholds or
+// the normal state of dry contacts), they have to be taken from the table of the respective sensor type.
+// Every table is walked only once, no matter how many sensors need it.
+// The sensors of the other devices are complete already.
+func AddTypeTableDetails(params Client, sensors []SensorDetails, deviceType int) error {
+	if deviceType != SensorProbePlusType {
+		return nil
 	}
 
-	// Name
-	details.Name = ValueToString(query.Variables[0])
+	var needed []int
 
-	// Sensor type
-	details.SensorType, err = ValueToUint64(query.Variables[1])
-	if err != nil {
-		return details, err
+	for _, sensor := range sensors {
+		table := typeTableOf(sensor.SensorType)
+		if table >= 0 && !slices.Contains(needed, table) {
+			needed = append(needed, table)
+		}
 	}
 
-	// The sensor Value (as seen in the interface)
-	details.Value, err = ValueIEEE754ToFloat64(query.Variables[7])
-	if err != nil {
-		return details, err
-	}
+	rows := make([][]SensorDetails, len(plusTypeTables))
+
+	err := forEachConcurrently(len(needed), concurrency(params), func(i int) error {
+		var err error
 
-	// The measuring unit (if any)
-	details.Unit = ValueToString(query.Variables[3])
+		rows[needed[i]], err = ReadTable(params, plusTypeTables[needed[i]].Table)
 
-	// Sensor status (is the value inside the thresholds configured on the device
-	tmp, err := ValueToUint64(query.Variables[4])
+		return err
+	})
 	if err != nil {
-		return details, err
+		return err
 	}
 
-	details.Status = snsrStts(tmp)
+	for i := range sensors {
+		sensor := &sensors[i]
+
+		table := typeTableOf(sensor.SensorType)
+		if table < 0 {
+			continue
+		}
 
-	if details.Status != Normal {
-		details.Description = ValueToString(query.Variables[5])
-	} else {
-		details.Description = ValueToString(query.Variables[6])
+		for _, row := range rows[table] {
+			if row.Err != nil {
+				continue
+			}
+
+			// Names are given by the user and often repeat, only the index identifies the sensor
+			if row.Index == sensor.Index {
+				plusTypeTables[table].merge(sensor, row)
+
+				break
+			}
+		}
 	}
 
-	return details, nil
+	return nil
 }
 
 func ValueToString(pdu gosnmp.SnmpPDU) string {
 	switch pdu.Type { //nolint: exhaustive
 	case gosnmp.OctetString:
 		return string(pdu.Value.([]byte))
+	case gosnmp.ObjectIdentifier:
+		return pdu.Value.(string)
 	default:
 		return fmt.Sprintf("%d", gosnmp.ToBigInt(pdu.Value))
 	}
@@ -456,21 +633,53 @@ func ValueToUint64(pdu gosnmp.SnmpPDU) (uint64, error) {
 			return val.Uint64(), nil
 		}
 
-		return 0, errors.New("value not in uint64")
+		return 0, valueError(pdu, "value not in uint64")
 	default:
-		return 0, errors.New("value is not an integer")
+		return 0, valueError(pdu, "value is not an integer")
 	}
 }
 
+func ValueToInt64(pdu gosnmp.SnmpPDU) (int64, error) {
+	switch pdu.Type { //nolint: exhaustive
+	case gosnmp.Integer:
+		var val = gosnmp.ToBigInt(pdu.Value)
+		if val.IsInt64() {
+			return val.Int64(), nil
+		}
+
+		return 0, valueError(pdu, "value not in int64")
+	default:
+		return 0, valueError(pdu, "value is not an integer")
+	}
+}
+
+// Converts via the shortest decimal representation, so 27.1 stays 27.1 instead of 27.100000381469727
+func float32ToFloat64(f float32) float64 {
+	result, err := strconv.ParseFloat(strconv.FormatFloat(float64(f), 'g', -1, 32), 64)
+	if err != nil {
+		return float64(f)
+	}
+
+	return result
+}
+
 func ValueIEEE754ToFloat64(pdu gosnmp.SnmpPDU) (float64, error) {
 	switch pdu.Type { //nolint: exhaustive
 	case gosnmp.Opaque:
 		tmp := pdu.Value.([]uint8)
+		if len(tmp) < 4 {
+			return 0, valueError(pdu, "opaque value is too short for a float")
+		}
+
 		bla := binary.LittleEndian.Uint32(tmp)
 		tmp2 := math.Float32frombits(bla)
 
-		return float64(tmp2), nil
+		return float32ToFloat64(tmp2), nil
+	case gosnmp.OpaqueFloat:
+		return float32ToFloat64(pdu.Value.(float32)), nil
+	case gosnmp.OpaqueDouble:
+		return pdu.Value.(float64), nil
 	default:
-		return 0, errors.New("value is not an Opaque")
+		return 0, valueError(pdu, "value is not an Opaque")
 	}
 }
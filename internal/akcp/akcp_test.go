@@ -0,0 +1,360 @@
+package akcp
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/capture"
+	"github.com/NETWAYS/go-check"
+	"github.com/gosnmp/gosnmp"
+)
+
+// Replays a capture in testdata
+func loadReplay(t *testing.T, name string) *capture.Replay {
+	t.Helper()
+
+	replay, err := capture.LoadFile("../../testdata/" + name)
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	return replay
+}
+
+// Replays the output of snmpwalk given by the test
+func parseReplay(t *testing.T, walk string) *capture.Replay {
+	t.Helper()
+
+	pdus, err := capture.ParseWalk(walk)
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	replay, err := capture.NewReplay(pdus)
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	return replay
+}
+
+func TestSensorIndexMatchesPort(t *testing.T) {
+	testcases := map[string]struct {
+		index    string
+		port     string
+		expected bool
+	}{
+		"completeIndex": {
+			index:    "1.2.1.1",
+			port:     "1.2.1.1",
+			expected: true,
+		},
+		"port": {
+			index:    "1.2.1.1",
+			port:     "2",
+			expected: true,
+		},
+		"portAndSubport": {
+			index:    "1.2.3.1",
+			port:     "2.3",
+			expected: true,
+		},
+		"otherSubport": {
+			index:    "1.2.3.1",
+			port:     "2.1",
+			expected: false,
+		},
+		"otherPort": {
+			index:    "1.2.1.1",
+			port:     "3",
+			expected: false,
+		},
+		"empty": {
+			index:    "1.2.1.1",
+			port:     "",
+			expected: false,
+		},
+		"name": {
+			index:    "1.2.1.1",
+			port:     "Temperature Port 2",
+			expected: false,
+		},
+		// The ports of the classic devices are counted from 0, the table comes first
+		"sensorProbeFirstPort": {
+			index:    "16.0",
+			port:     "0",
+			expected: true,
+		},
+		"sensorProbeTableAndPort": {
+			index:    "17.0",
+			port:     "17.0",
+			expected: true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			actual := SensorIndexMatchesPort(tc.index, tc.port)
+
+			if actual != tc.expected {
+				t.Error("\nActual: ", actual, "\nExpected: ", tc.expected)
+			}
+		})
+	}
+}
+
+func TestQueryAllSensorDetails(t *testing.T) {
+	testcases := map[string]struct {
+		walk          string
+		deviceType    int
+		expectedNames []string
+		expectedGets  uint64
+		expectedWalks uint64
+	}{
+		"sensorProbePlus": {
+			walk:          "sensorProbePlus.walk",
+			deviceType:    SensorProbePlusType,
+			expectedNames: []string{"Temperature Port 1", "Dual Humidity Port 2", "Dual Temperature Port 2", "Airflow Port 3", "Buzzer"},
+			// One walk per column of the common table, one per type table
+			expectedWalks: 10,
+		},
+		"sensorProbePlusContacts": {
+			walk:          "sensorProbePlusContacts.walk",
+			deviceType:    SensorProbePlusType,
+			expectedNames: []string{"Server Room Door", "UPS Alarm", "CRAC Fault Output"},
+			expectedWalks: 8,
+		},
+		"sensorProbePlusAnalog": {
+			walk:          "sensorProbePlusAnalog.walk",
+			deviceType:    SensorProbePlusType,
+			expectedNames: []string{"Diff Pressure", "Tank Level", "Battery Voltage", "Mains Voltage"},
+			// The 4-20 mA table and both voltage tables
+			expectedWalks: 10,
+		},
+		"sensorProbePlusAirflow": {
+			walk:          "sensorProbePlusAirflow.walk",
+			deviceType:    SensorProbePlusType,
+			expectedNames: []string{"Rack Fan 1", "Rack Fan 2", "CRAC Airflow"},
+			expectedWalks: 8,
+		},
+		"sensorProbePlusWaterRope": {
+			walk:          "sensorProbePlusWaterRope.walk",
+			deviceType:    SensorProbePlusType,
+			expectedNames: []string{"Raised Floor A", "Raised Floor B", "UPS Room"},
+			expectedWalks: 8,
+		},
+		"sensorProbe": {
+			walk:          "sensorProbe.walk",
+			deviceType:    SensorProbeType,
+			expectedNames: []string{"Freezer", "Freezer Humidity", "Door Contact"},
+			// The online column and the table itself for each of the three tables
+			expectedWalks: 6,
+		},
+		"securityProbe": {
+			walk:          "securityProbe.walk",
+			deviceType:    SecurityProbeType,
+			expectedNames: []string{"Rack Inlet", "Rack Humidity", "Door Contact", "Cage Motion", "Alarm Siren"},
+			// The tables of the sensorProbe and the security and siren tables
+			expectedWalks: 10,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			client := NewCountingClient(loadReplay(t, tc.walk))
+
+			sensors, err := QueryAllSensorDetails(client, tc.deviceType)
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+
+			err = AddTypeTableDetails(client, sensors, tc.deviceType)
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+
+			actualNames := make([]string, 0, len(sensors))
+			for _, sensor := range sensors {
+				actualNames = append(actualNames, sensor.Name)
+			}
+
+			if !slices.Equal(actualNames, tc.expectedNames) {
+				t.Error("\nActual: ", actualNames, "\nExpected: ", tc.expectedNames)
+			}
+
+			gets, walks := client.Requests()
+			if gets != tc.expectedGets || walks != tc.expectedWalks {
+				t.Error("\nActual: ", gets, walks, "\nExpected: ", tc.expectedGets, tc.expectedWalks)
+			}
+
+			for _, sensor := range sensors {
+				if sensor.SensorType == sensorProbePlus.Temperature && !sensor.Warning.Present {
+					t.Error("Expected thresholds for ", sensor.Name)
+				}
+
+				if (sensor.SensorType == sensorProbePlus.Four_20mA || sensor.SensorType == sensorProbePlus.Dcvoltage ||
+					sensor.SensorType == sensorProbePlus.Acvoltage) && !sensor.Critical.Present {
+					t.Error("Expected thresholds for ", sensor.Name)
+				}
+
+				// A high airflow is no problem
+				if sensor.SensorType == sensorProbePlus.Airflow && sensor.Critical.Present && sensor.Critical.Val.Upper != check.PosInf {
+					t.Error("Expected only low thresholds for ", sensor.Name)
+				}
+
+				if IsWaterRope(sensor.SensorType) && (sensor.RopeLength == 0 || sensor.Unit == "") {
+					t.Error("Expected the length and unit of ", sensor.Name)
+				}
+
+				if IsContact(sensor.SensorType) && (sensor.NormalState == ContactStateUnknown || sensor.Direction == ContactDirectionUnknown) {
+					t.Error("Expected the normal state and direction of ", sensor.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestLeakDistance(t *testing.T) {
+	testcases := map[string]struct {
+		sensor           SensorDetails
+		expectedDistance float64
+		expectedKnown    bool
+	}{
+		"leak": {
+			sensor:           SensorDetails{Status: HighCritical, Value: 12, RopeLength: 50},
+			expectedDistance: 12,
+			expectedKnown:    true,
+		},
+		"leakAtStart": {
+			sensor:        SensorDetails{Status: HighCritical, Value: 0, RopeLength: 50},
+			expectedKnown: true,
+		},
+		"lengthUnknown": {
+			sensor:           SensorDetails{Status: HighCritical, Value: 12},
+			expectedDistance: 12,
+			expectedKnown:    true,
+		},
+		"beyondRope": {
+			sensor:        SensorDetails{Status: HighCritical, Value: 65535, RopeLength: 50},
+			expectedKnown: false,
+		},
+		"noLeak": {
+			sensor:        SensorDetails{Status: Normal, Value: 12, RopeLength: 50},
+			expectedKnown: false,
+		},
+		"ropeBroken": {
+			sensor:        SensorDetails{Status: SensorError, Value: 12, RopeLength: 50},
+			expectedKnown: false,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			distance, known := tc.sensor.LeakDistance()
+
+			if distance != tc.expectedDistance || known != tc.expectedKnown {
+				t.Error("\nActual: ", distance, known, "\nExpected: ", tc.expectedDistance, tc.expectedKnown)
+			}
+		})
+	}
+}
+
+// A device which does not answer
+type silentClient struct{}
+
+func (silentClient) Get(_ []string) (*gosnmp.SnmpPacket, error) {
+	return nil, errors.New("request timeout (after 2 retries)")
+}
+
+func (silentClient) BulkWalkAll(_ string) ([]gosnmp.SnmpPDU, error) {
+	return nil, errors.New("request timeout (after 2 retries)")
+}
+
+func TestDetectDeviceType(t *testing.T) {
+	testcases := map[string]struct {
+		walk         string
+		client       Client
+		expectedType int
+		expectedErr  error
+		expectedGets uint64
+	}{
+		"sensorProbePlus": {
+			walk:         "sensorProbePlus.walk",
+			expectedType: SensorProbePlusType,
+			expectedGets: 1,
+		},
+		"sensorProbe": {
+			walk:         "sensorProbe.walk",
+			expectedType: SensorProbeType,
+			expectedGets: 2,
+		},
+		"securityProbe": {
+			walk:         "securityProbe.walk",
+			expectedType: SecurityProbeType,
+			expectedGets: 2,
+		},
+		"noAKCPDevice": {
+			client:       &capture.Replay{},
+			expectedErr:  ErrUnsupportedDevice,
+			expectedGets: 2,
+		},
+		// The device is not asked a second time
+		"noAnswer": {
+			client:       silentClient{},
+			expectedErr:  ErrTimeout,
+			expectedGets: 1,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			client := tc.client
+			if tc.walk != "" {
+				client = loadReplay(t, tc.walk)
+			}
+
+			counting := NewCountingClient(client)
+
+			actual, err := DetectDeviceType(counting)
+			if !errors.Is(err, tc.expectedErr) || (tc.expectedErr == nil && err != nil) {
+				t.Error("\nActual: ", err, "\nExpected: ", tc.expectedErr)
+			}
+
+			if actual != tc.expectedType {
+				t.Error("\nActual: ", actual, "\nExpected: ", tc.expectedType)
+			}
+
+			gets, _ := counting.Requests()
+			if gets != tc.expectedGets {
+				t.Error("\nActual: ", gets, "\nExpected: ", tc.expectedGets)
+			}
+		})
+	}
+}
+
+// Sensors with the same name on different ports keep the details of their own row
+func TestAddTypeTableDetailsByIndex(t *testing.T) {
+	replay := parseReplay(t, `.1.3.6.1.4.1.3854.3.5.2.1.2.1.2.1.1 = STRING: "Temperature"
+.1.3.6.1.4.1.3854.3.5.2.1.11.1.2.1.1 = INTEGER: 300
+.1.3.6.1.4.1.3854.3.5.2.1.12.1.2.1.1 = INTEGER: 400`)
+
+	sensors := []SensorDetails{
+		{Index: "1.1.1.1", Name: "Temperature", SensorType: sensorProbePlus.Temperature},
+		{Index: "1.2.1.1", Name: "Temperature", SensorType: sensorProbePlus.Temperature},
+	}
+
+	err := AddTypeTableDetails(replay, sensors, SensorProbePlusType)
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	if sensors[0].Critical.Present {
+		t.Error("\nActual: ", sensors[0].Critical, "\nExpected: no thresholds for ", sensors[0].Index)
+	}
+
+	if !sensors[1].Critical.Present || sensors[1].Critical.Val.Upper != 40 {
+		t.Error("\nActual: ", sensors[1].Critical, "\nExpected: thresholds up to 40 for ", sensors[1].Index)
+	}
+}
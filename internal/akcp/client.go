@@ -0,0 +1,89 @@
+package akcp
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// The SNMP operations needed to query a device
+// Implemented by *gosnmp.GoSNMP and by replays of captured devices
+type Client interface {
+	Get(oids []string) (*gosnmp.SnmpPacket, error)
+	BulkWalkAll(rootOid string) ([]gosnmp.SnmpPDU, error)
+}
+
+// Counts the requests to the device, a walk may need several round trips
+type CountingClient struct {
+	client Client
+	gets   atomic.Uint64
+	walks  atomic.Uint64
+}
+
+func NewCountingClient(client Client) *CountingClient {
+	return &CountingClient{client: client}
+}
+
+func (c *CountingClient) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	c.gets.Add(1)
+
+	return c.client.Get(oids)
+}
+
+func (c *CountingClient) BulkWalkAll(rootOid string) ([]gosnmp.SnmpPDU, error) {
+	c.walks.Add(1)
+
+	return c.client.BulkWalkAll(rootOid)
+}
+
+func (c *CountingClient) Concurrency() int {
+	return concurrency(c.client)
+}
+
+// Returns the number of GET requests and walks
+func (c *CountingClient) Requests() (gets uint64, walks uint64) {
+	return c.gets.Load(), c.walks.Load()
+}
+
+// Stops all requests once the context is done, e.g. when the time of the check is up
+// The functions of this package return ErrDeadline in that case
+type ContextClient struct {
+	client Client
+	ctx    context.Context
+}
+
+func NewContextClient(ctx context.Context, client Client) *ContextClient {
+	return &ContextClient{client: client, ctx: ctx}
+}
+
+func (c *ContextClient) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	query, err := c.client.Get(oids)
+	if err != nil && c.ctx.Err() != nil {
+		// The request was cut short by the context
+		return nil, c.ctx.Err()
+	}
+
+	return query, err
+}
+
+func (c *ContextClient) BulkWalkAll(rootOid string) ([]gosnmp.SnmpPDU, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pdus, err := c.client.BulkWalkAll(rootOid)
+	if err != nil && c.ctx.Err() != nil {
+		return nil, c.ctx.Err()
+	}
+
+	return pdus, err
+}
+
+func (c *ContextClient) Concurrency() int {
+	return concurrency(c.client)
+}
@@ -0,0 +1,111 @@
+package akcp
+
+import (
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+	"github.com/gosnmp/gosnmp"
+)
+
+// The state of a dry contact
+type ContactState int
+
+const (
+	ContactStateUnknown ContactState = iota
+	ContactOpen
+	ContactClosed
+)
+
+func (s ContactState) String() string {
+	switch s {
+	case ContactOpen:
+		return "open"
+	case ContactClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// The opposite state, unknown stays unknown
+func (s ContactState) Inverse() ContactState {
+	switch s {
+	case ContactOpen:
+		return ContactClosed
+	case ContactClosed:
+		return ContactOpen
+	default:
+		return ContactStateUnknown
+	}
+}
+
+// Whether a dry contact is read by the device or switched by it
+type ContactDirection int
+
+const (
+	ContactDirectionUnknown ContactDirection = iota
+	ContactInput
+	ContactOutput
+)
+
+func (d ContactDirection) String() string {
+	switch d {
+	case ContactInput:
+		return "input"
+	case ContactOutput:
+		return "output"
+	default:
+		return "unknown"
+	}
+}
+
+// Returns whether the sensor is a dry contact
+func IsContact(sensorType uint64) bool {
+	return sensorType == sensorProbePlus.Dry_inout || sensorType == sensorProbePlus.Dry_in
+}
+
+// The current state of a dry contact, the device only reports whether the contact is in its normal state
+func (s SensorDetails) ContactState() ContactState {
+	switch s.Status {
+	case Normal:
+		return s.NormalState
+	case HighWarning, HighCritical, LowWarning, LowCritical:
+		return s.NormalState.Inverse()
+	default:
+		return ContactStateUnknown
+	}
+}
+
+func DecodeContactNormalState(pdu gosnmp.SnmpPDU, _ float64, details *SensorDetails) error {
+	tmp, err := ValueToUint64(pdu)
+	if err != nil {
+		return err
+	}
+
+	switch tmp {
+	case sensorProbePlus.DrycontactNormalClosed:
+		details.NormalState = ContactClosed
+	case sensorProbePlus.DrycontactNormalOpen:
+		details.NormalState = ContactOpen
+	default:
+		return valueError(pdu, "unknown normal state of the contact")
+	}
+
+	return nil
+}
+
+func DecodeContactDirection(pdu gosnmp.SnmpPDU, _ float64, details *SensorDetails) error {
+	tmp, err := ValueToUint64(pdu)
+	if err != nil {
+		return err
+	}
+
+	switch tmp {
+	case sensorProbePlus.DrycontactInput:
+		details.Direction = ContactInput
+	case sensorProbePlus.DrycontactOutput:
+		details.Direction = ContactOutput
+	default:
+		return valueError(pdu, "unknown direction of the contact")
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package akcp
+
+import (
+	"github.com/gosnmp/gosnmp"
+)
+
+// The engineering values a 4-20 mA sensor maps the current of its loop to (e.g. 0 to 500 Pa)
+// If the mapping is not configured on the device, both values are 0 and the value of the sensor is the current
+type CurrentLoopRange struct {
+	// The value at 4 mA
+	Low float64
+	// The value at 20 mA
+	High float64
+}
+
+const (
+	loopCurrentLow  = 4
+	loopCurrentHigh = 20
+)
+
+func (r CurrentLoopRange) Configured() bool {
+	return r.Low != r.High
+}
+
+// Returns the engineering value of the current (in mA)
+func (r CurrentLoopRange) ToValue(current float64) float64 {
+	return r.Low + (current-loopCurrentLow)*(r.High-r.Low)/(loopCurrentHigh-loopCurrentLow)
+}
+
+// Returns the current (in mA) of the engineering value
+func (r CurrentLoopRange) ToCurrent(value float64) float64 {
+	return loopCurrentLow + (value-r.Low)*(loopCurrentHigh-loopCurrentLow)/(r.High-r.Low)
+}
+
+// Returns the current (in mA) of the loop of a 4-20 mA sensor
+func (s SensorDetails) LoopCurrent() float64 {
+	if !s.LoopRange.Configured() {
+		return s.Value
+	}
+
+	return s.LoopRange.ToCurrent(s.Value)
+}
+
+func DecodeLoopRangeLow(pdu gosnmp.SnmpPDU, scale float64, details *SensorDetails) error {
+	tmp, err := ValueToInt64(pdu)
+	if err != nil {
+		return err
+	}
+
+	details.LoopRange.Low = float64(tmp) / scale
+
+	return nil
+}
+
+func DecodeLoopRangeHigh(pdu gosnmp.SnmpPDU, scale float64, details *SensorDetails) error {
+	tmp, err := ValueToInt64(pdu)
+	if err != nil {
+		return err
+	}
+
+	details.LoopRange.High = float64(tmp) / scale
+
+	return nil
+}
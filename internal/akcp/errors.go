@@ -0,0 +1,134 @@
+package akcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// The kinds of errors returned by this package, use errors.Is to tell them apart
+// The underlying error of gosnmp is wrapped as well
+var (
+	ErrTimeout           = errors.New("device did not answer")
+	ErrDeadline          = errors.New("time of the check is up")
+	ErrAuthentication    = errors.New("authentication failed")
+	ErrNoSuchObject      = errors.New("no such object")
+	ErrNoSuchInstance    = errors.New("no such instance")
+	ErrUnsupportedDevice = errors.New("unsupported device")
+	ErrDecode            = errors.New("value could not be decoded")
+)
+
+// An error of a request to the device or of a value in its response
+type Error struct {
+	// One of the kinds of errors above
+	Kind error
+	// The OID the error refers to, if any
+	OID string
+	// The cause, may be nil
+	Err error
+}
+
+func (e *Error) Error() string {
+	msg := e.Kind.Error()
+
+	if e.OID != "" {
+		msg += " at " + e.OID
+	}
+
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+
+	return []error{e.Kind, e.Err}
+}
+
+// Classifies the error of a request, errors which fit none of the kinds (e.g. a refused connection)
+// are returned as they are
+func requestError(err error, oid string) error {
+	var akcpErr *Error
+	if err == nil || errors.As(err, &akcpErr) {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, gosnmp.ErrUnknownUsername),
+		errors.Is(err, gosnmp.ErrWrongDigest),
+		errors.Is(err, gosnmp.ErrDecryption),
+		errors.Is(err, gosnmp.ErrUnknownSecurityLevel),
+		errors.Is(err, gosnmp.ErrNotInTimeWindow),
+		errors.Is(err, gosnmp.ErrUnknownEngineID):
+		return &Error{Kind: ErrAuthentication, OID: oid, Err: err}
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return &Error{Kind: ErrDeadline, OID: oid, Err: err}
+	case isTimeout(err):
+		return &Error{Kind: ErrTimeout, OID: oid, Err: err}
+	default:
+		return err
+	}
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	// gosnmp reports exhausted retries only as text
+	return strings.Contains(err.Error(), "request timeout")
+}
+
+// Returned by the decoders if a value does not have the expected type
+func valueError(pdu gosnmp.SnmpPDU, cause string) error {
+	switch pdu.Type { //nolint: exhaustive
+	case gosnmp.NoSuchObject:
+		return &Error{Kind: ErrNoSuchObject, OID: pdu.Name}
+	case gosnmp.NoSuchInstance:
+		return &Error{Kind: ErrNoSuchInstance, OID: pdu.Name}
+	default:
+		return &Error{Kind: ErrDecode, OID: pdu.Name, Err: errors.New(cause)}
+	}
+}
+
+func unsupportedDevice(deviceType int) error {
+	return &Error{Kind: ErrUnsupportedDevice, Err: fmt.Errorf("not implemented for device type %s", GetDeviceTypeName(deviceType))}
+}
+
+// The requests of this package, with classified errors
+func get(params Client, oids []string) (*gosnmp.SnmpPacket, error) {
+	query, err := params.Get(oids)
+	if err != nil {
+		oid := ""
+		if len(oids) == 1 {
+			oid = oids[0]
+		}
+
+		return nil, requestError(err, oid)
+	}
+
+	if len(query.Variables) != len(oids) {
+		return nil, &Error{Kind: ErrDecode, Err: errors.New("unexpected number of values in the response")}
+	}
+
+	return query, nil
+}
+
+func walk(params Client, rootOid string) ([]gosnmp.SnmpPDU, error) {
+	pdus, err := params.BulkWalkAll(rootOid)
+	if err != nil {
+		return nil, requestError(err, rootOid)
+	}
+
+	return pdus, nil
+}
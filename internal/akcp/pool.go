@@ -0,0 +1,159 @@
+package akcp
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// Distributes the requests over several sessions to the device, so independent tables can be
+// fetched concurrently. A session is only used by one request at a time, additional sessions
+// are opened when they are needed, up to the size of the pool.
+type SessionPool struct {
+	open func() (Client, func(), error)
+	size int
+
+	// Limits the requests in flight to the size of the pool
+	slots chan struct{}
+
+	mutex   sync.Mutex
+	idle    []Client
+	closers []func()
+}
+
+// Creates a pool with an already opened session, open is used to create further sessions
+func NewSessionPool(session Client, closeSession func(), open func() (Client, func(), error), size int) *SessionPool {
+	if size < 1 {
+		size = 1
+	}
+
+	return &SessionPool{
+		open:    open,
+		size:    size,
+		slots:   make(chan struct{}, size),
+		idle:    []Client{session},
+		closers: []func(){closeSession},
+	}
+}
+
+func (p *SessionPool) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	session, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer p.release(session)
+
+	return session.Get(oids)
+}
+
+func (p *SessionPool) BulkWalkAll(rootOid string) ([]gosnmp.SnmpPDU, error) {
+	session, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer p.release(session)
+
+	return session.BulkWalkAll(rootOid)
+}
+
+// The number of requests which may be sent at the same time
+func (p *SessionPool) Concurrency() int {
+	return p.size
+}
+
+// Closes all sessions, the pool must not be used afterwards
+func (p *SessionPool) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, closeSession := range p.closers {
+		closeSession()
+	}
+
+	p.closers = nil
+	p.idle = nil
+}
+
+func (p *SessionPool) acquire() (Client, error) {
+	p.slots <- struct{}{}
+
+	p.mutex.Lock()
+
+	if len(p.idle) > 0 {
+		session := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		p.mutex.Unlock()
+
+		return session, nil
+	}
+
+	p.mutex.Unlock()
+
+	// There is a free slot, but every session is busy
+	session, closeSession, err := p.open()
+	if err != nil {
+		<-p.slots
+
+		return nil, err
+	}
+
+	p.mutex.Lock()
+	p.closers = append(p.closers, closeSession)
+	p.mutex.Unlock()
+
+	return session, nil
+}
+
+func (p *SessionPool) release(session Client) {
+	p.mutex.Lock()
+	p.idle = append(p.idle, session)
+	p.mutex.Unlock()
+
+	<-p.slots
+}
+
+// Returns how many requests may be sent through the client at the same time
+func concurrency(client Client) int {
+	tmp, ok := client.(interface{ Concurrency() int })
+	if !ok || tmp.Concurrency() < 1 {
+		return 1
+	}
+
+	return tmp.Concurrency()
+}
+
+// Calls fn for every index from 0 to n-1, at most limit calls run at the same time
+// The errors are joined in the order of the indexes, independent of the order in which the calls finish
+func forEachConcurrently(n int, limit int, fn func(i int) error) error {
+	errs := make([]error, n)
+
+	if limit <= 1 {
+		for i := range n {
+			errs[i] = fn(i)
+		}
+
+		return errors.Join(errs...)
+	}
+
+	slots := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+
+	for i := range n {
+		slots <- struct{}{}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			errs[i] = fn(i)
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
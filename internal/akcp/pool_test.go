@@ -0,0 +1,97 @@
+package akcp
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// A session which fails the test if it is used by more than one request at a time
+type exclusiveSession struct {
+	t      *testing.T
+	client Client
+	inUse  atomic.Bool
+}
+
+func (s *exclusiveSession) use() func() {
+	if !s.inUse.CompareAndSwap(false, true) {
+		s.t.Error("Session is used concurrently")
+	}
+
+	// Give the other requests a chance to run at the same time
+	time.Sleep(time.Millisecond)
+
+	return func() { s.inUse.Store(false) }
+}
+
+func (s *exclusiveSession) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	defer s.use()()
+
+	return s.client.Get(oids)
+}
+
+func (s *exclusiveSession) BulkWalkAll(rootOid string) ([]gosnmp.SnmpPDU, error) {
+	defer s.use()()
+
+	return s.client.BulkWalkAll(rootOid)
+}
+
+func TestSessionPool(t *testing.T) {
+	replay := loadReplay(t, "sensorProbePlus.walk")
+
+	expected, err := QueryAllSensorDetails(replay, SensorProbePlusType)
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	var opened atomic.Int32
+
+	open := func() (Client, func(), error) {
+		opened.Add(1)
+
+		return &exclusiveSession{t: t, client: replay}, func() {}, nil
+	}
+
+	pool := NewSessionPool(&exclusiveSession{t: t, client: replay}, func() {}, open, 3)
+	defer pool.Close()
+
+	actual, err := QueryAllSensorDetails(pool, SensorProbePlusType)
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	if fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+
+	if opened.Load() > 2 {
+		t.Error("\nActual: ", opened.Load(), "\nExpected: at most 2 additional sessions")
+	}
+}
+
+func TestForEachConcurrently(t *testing.T) {
+	for _, limit := range []int{1, 4} {
+		t.Run(fmt.Sprint(limit), func(t *testing.T) {
+			err := forEachConcurrently(5, limit, func(i int) error {
+				// The later indexes finish first
+				time.Sleep(time.Duration(5-i) * time.Millisecond)
+
+				if i%2 == 1 {
+					return fmt.Errorf("error %d", i)
+				}
+
+				return nil
+			})
+
+			expected := errors.Join(errors.New("error 1"), errors.New("error 3")).Error()
+
+			if err == nil || err.Error() != expected {
+				t.Error("\nActual: ", err, "\nExpected: ", expected)
+			}
+		})
+	}
+}
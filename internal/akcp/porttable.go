@@ -0,0 +1,248 @@
+package akcp
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/securityProbe"
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbe"
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+	"github.com/gosnmp/gosnmp"
+)
+
+// The classic devices have no common sensor table, every kind of sensor has its own
+// table which is indexed by the port number.
+// A sensor on these devices is therefore identified by "<table number>.<port>", e.g. "16.0"
+// for the temperature sensor on the first port.
+// The sensor type is given in terms of sensorProbePlus, which is used as common denominator.
+type portTable struct {
+	Table
+	// Only the sensors which are online are of interest
+	online string
+}
+
+// The degree type column tells whether the temperature is given in Fahrenheit or Celsius
+func decodeDegreeType(pdu gosnmp.SnmpPDU, _ float64, details *SensorDetails) error {
+	tmp, err := ValueToUint64(pdu)
+	if err != nil {
+		return err
+	}
+
+	if tmp == sensorProbe.Fahrenheit {
+		details.Unit = "F"
+	} else {
+		details.Unit = "C"
+	}
+
+	return nil
+}
+
+var sensorProbeTables = []portTable{
+	{
+		Table: Table{
+			OID:        sensorProbe.TemperatureTable,
+			SensorType: sensorProbePlus.Temperature,
+			Columns: []Column{
+				{OID: sensorProbe.SensorTemperatureDescription, Decode: DecodeName},
+				{OID: sensorProbe.SensorTemperatureDegree, Decode: DecodeValue, Required: true},
+				{OID: sensorProbe.SensorTemperatureStatus, Decode: DecodeStatus, Required: true},
+				{OID: sensorProbe.SensorTemperatureLowCritical, Decode: DecodeLowCritical},
+				{OID: sensorProbe.SensorTemperatureLowWarning, Decode: DecodeLowWarning},
+				{OID: sensorProbe.SensorTemperatureHighWarning, Decode: DecodeHighWarning},
+				{OID: sensorProbe.SensorTemperatureHighCritical, Decode: DecodeHighCritical},
+				{OID: sensorProbe.SensorTemperatureDegreeType, Decode: decodeDegreeType},
+			},
+		},
+		online: sensorProbe.SensorTemperatureOnline,
+	},
+	{
+		Table: Table{
+			OID:        sensorProbe.HumidityTable,
+			SensorType: sensorProbePlus.Humidity_dual,
+			Unit:       "%",
+			Columns: []Column{
+				{OID: sensorProbe.SensorHumidityDescription, Decode: DecodeName},
+				{OID: sensorProbe.SensorHumidityPercent, Decode: DecodeValue, Required: true},
+				{OID: sensorProbe.SensorHumidityStatus, Decode: DecodeStatus, Required: true},
+				{OID: sensorProbe.SensorHumidityLowCritical, Decode: DecodeLowCritical},
+				{OID: sensorProbe.SensorHumidityLowWarning, Decode: DecodeLowWarning},
+				{OID: sensorProbe.SensorHumidityHighWarning, Decode: DecodeHighWarning},
+				{OID: sensorProbe.SensorHumidityHighCritical, Decode: DecodeHighCritical},
+			},
+		},
+		online: sensorProbe.SensorHumidityOnline,
+	},
+	{
+		Table: Table{
+			OID:        sensorProbe.SwitchTable,
+			SensorType: sensorProbePlus.Dry_inout,
+			Columns: []Column{
+				{OID: sensorProbe.SensorSwitchDescription, Decode: DecodeName},
+				{OID: sensorProbe.SensorSwitchStatus, Decode: DecodeStatusWithValue, Required: true},
+				{OID: sensorProbe.SensorSwitchNormalState, Decode: DecodeContactNormalState},
+				{OID: sensorProbe.SensorSwitchDirection, Decode: DecodeContactDirection},
+			},
+		},
+		online: sensorProbe.SensorSwitchOnline,
+	},
+}
+
+// The securityProbe has the tables of the sensorProbe and some of its own
+var securityProbeTables = append(slices.Clone(sensorProbeTables), []portTable{
+	{
+		Table: Table{
+			OID:        securityProbe.SecurityTable,
+			SensorType: sensorProbePlus.Security,
+			Columns: []Column{
+				{OID: securityProbe.SensorSecurityDescription, Decode: DecodeName},
+				{OID: securityProbe.SensorSecurityStatus, Decode: DecodeStatusWithValue, Required: true},
+			},
+		},
+		online: securityProbe.SensorSecurityOnline,
+	},
+	{
+		Table: Table{
+			OID:        securityProbe.SirenTable,
+			SensorType: sensorProbePlus.Siren,
+			Columns: []Column{
+				{OID: securityProbe.SensorSirenDescription, Decode: DecodeName},
+				{OID: securityProbe.SensorSirenStatus, Decode: DecodeStatusWithValue, Required: true},
+			},
+		},
+		online: securityProbe.SensorSirenOnline,
+	},
+}...)
+
+// Translation of the sensor types of the sensorProbe into the ones of the sensorProbe+
+var sensorProbeToPlusType = map[sensorProbe.SensorType]sensorProbePlus.SensorType{
+	sensorProbe.Temperature: sensorProbePlus.Temperature,
+	sensorProbe.Four_20mA:   sensorProbePlus.Four_20mA,
+	sensorProbe.Humidity:    sensorProbePlus.Humidity_dual,
+	sensorProbe.Water:       sensorProbePlus.Water,
+	sensorProbe.Atod:        sensorProbePlus.Dcvoltage,
+	sensorProbe.Security:    sensorProbePlus.Security,
+	sensorProbe.Airflow:     sensorProbePlus.Airflow,
+	sensorProbe.Siren:       sensorProbePlus.Siren,
+	sensorProbe.Dry_contact: sensorProbePlus.Dry_inout,
+	sensorProbe.Voltage:     sensorProbePlus.Acvoltage,
+	sensorProbe.Relay:       sensorProbePlus.Relay,
+	sensorProbe.Motion:      sensorProbePlus.Motion,
+}
+
+// Translation of the sensor types of the securityProbe into the ones of the sensorProbe+
+var securityProbeToPlusType = func() map[securityProbe.SensorType]sensorProbePlus.SensorType {
+	types := maps.Clone(sensorProbeToPlusType)
+	types[securityProbe.Smoke] = sensorProbePlus.Smoke
+
+	return types
+}()
+
+// Returns the port tables of the classic devices
+func portTablesOf(deviceType int) []portTable {
+	switch deviceType {
+	case SensorProbeType:
+		return sensorProbeTables
+	case SecurityProbeType:
+		return securityProbeTables
+	default:
+		return nil
+	}
+}
+
+// The number of the table within its parent, used as first part of the sensor index
+func (t portTable) number() string {
+	return t.OID[strings.LastIndex(t.OID, ".")+1:]
+}
+
+// Fetches the indexes of all sensors which are currently online
+func queryPortSensorList(params Client, tables []portTable) (sensors []string, err error) {
+	for _, table := range tables {
+		oid := akcpBaseOID + table.online
+
+		results, err := walk(params, oid)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, variable := range results {
+			online, err := ValueToUint64(variable)
+			if err != nil {
+				return nil, err
+			}
+
+			if online != sensorProbe.Online {
+				continue
+			}
+
+			sensors = append(sensors, table.number()+"."+strings.TrimPrefix(variable.Name, oid+"."))
+		}
+	}
+
+	return sensors, nil
+}
+
+// Splits a sensor index into the table it belongs to and the port
+// The port may consist of several parts (e.g. for the expansion boards)
+func findPortTable(tables []portTable, sensorIndex string) (portTable, string, error) {
+	number, port, found := strings.Cut(sensorIndex, ".")
+	if !found {
+		return portTable{}, "", fmt.Errorf("invalid sensor index %s", sensorIndex)
+	}
+
+	for _, table := range tables {
+		if table.number() == number {
+			return table, port, nil
+		}
+	}
+
+	return portTable{}, "", fmt.Errorf("invalid sensor index %s", sensorIndex)
+}
+
+// Fetches all the sensors in a single port table which are online
+// The table is walked as a whole, the ports are only a handful of rows
+func queryPortTable(params Client, table portTable) ([]SensorDetails, error) {
+	sensors, err := queryPortSensorList(params, []portTable{table})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sensors) == 0 {
+		return nil, nil
+	}
+
+	rows, err := ReadTable(params, table.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	online := make(map[string]bool, len(sensors))
+	for _, sensor := range sensors {
+		online[sensor] = true
+	}
+
+	result := make([]SensorDetails, 0, len(sensors))
+
+	for _, details := range rows {
+		details.Index = table.number() + "." + details.Index
+
+		if online[details.Index] {
+			result = append(result, details)
+		}
+	}
+
+	return result, nil
+}
+
+// Looks up the table of the given sensor type
+func portTableByType(tables []portTable, sensorType uint64) (portTable, error) {
+	for _, table := range tables {
+		if table.SensorType == sensorType {
+			return table, nil
+		}
+	}
+
+	return portTable{}, errors.New("sensor type not available for this device")
+}
@@ -0,0 +1,53 @@
+package securityProbe
+
+import (
+	"maps"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbe"
+)
+
+// Taken from the AKCP securityProbe MIB (securityProbe 5E, 5ES and the SEC-5 expansion boards)
+// The MIB extends the one of the sensorProbe, the sensor types, the values of the columns and the
+// temperature, humidity and switch tables are the same and declared in package sensorProbe.
+// Additional sensor type of the securityProbe: smoke = 16
+const (
+	Smoke = 16
+)
+
+type SensorType = sensorProbe.SensorType
+
+var SensorsTypes = func() map[string]SensorType {
+	types := maps.Clone(sensorProbe.SensorsTypes)
+	types["smoke"] = Smoke
+
+	return types
+}()
+
+// The sensors of the SEC-5 expansion boards are listed in the same tables,
+// following the ports of the base unit
+const (
+	SecurityTable = sensorProbe.SensorArray + ".19"
+	SirenTable    = sensorProbe.SensorArray + ".20"
+)
+
+const (
+	SecurityTableEntry = SecurityTable + ".1"
+
+	SensorSecurityDescription = SecurityTableEntry + ".1"
+	SensorSecurityLocation    = SecurityTableEntry + ".2"
+	SensorSecurityStatus      = SecurityTableEntry + ".3"
+	SensorSecurityOnline      = SecurityTableEntry + ".4"
+	SensorSecurityGoOffline   = SecurityTableEntry + ".5"
+	SensorSecurityArmed       = SecurityTableEntry + ".6"
+)
+
+const (
+	SirenTableEntry = SirenTable + ".1"
+
+	SensorSirenDescription = SirenTableEntry + ".1"
+	SensorSirenLocation    = SirenTableEntry + ".2"
+	SensorSirenStatus      = SirenTableEntry + ".3"
+	SensorSirenOnline      = SirenTableEntry + ".4"
+	SensorSirenGoOffline   = SirenTableEntry + ".5"
+	SensorSirenControl     = SirenTableEntry + ".6"
+)
@@ -0,0 +1,103 @@
+package sensorProbe
+
+// Taken from the AKCP SPAGENT MIB (sensorProbe2, sensorProbe8)
+// temperature = 1,four-20mA = 2,humidity = 3,water = 4,atod = 5,security = 6,airflow = 8,siren = 9,dry-contact = 10,voltage = 12,relay = 13,motion = 14,
+const (
+	Temperature = 1
+	Four_20mA   = 2
+	Humidity    = 3
+	Water       = 4
+	Atod        = 5
+	Security    = 6
+	Airflow     = 8
+	Siren       = 9
+	Dry_contact = 10
+	Voltage     = 12
+	Relay       = 13
+	Motion      = 14
+)
+
+type SensorType uint64
+
+var SensorsTypes = map[string]SensorType{
+	"temperature": Temperature,
+	"four_20mA":   Four_20mA,
+	"humidity":    Humidity,
+	"water":       Water,
+	"atod":        Atod,
+	"security":    Security,
+	"airflow":     Airflow,
+	"siren":       Siren,
+	"dry_contact": Dry_contact,
+	"voltage":     Voltage,
+	"relay":       Relay,
+	"motion":      Motion,
+}
+
+// Values of the online columns
+const (
+	Online  = 1
+	Offline = 2
+)
+
+// Values of the degree type column of the temperature table
+const (
+	Fahrenheit = 0
+	Celsius    = 1
+)
+
+const (
+	SensorProbeID = ".1"
+	SensorArray   = SensorProbeID + ".2.2.1"
+
+	TemperatureTable = SensorArray + ".16"
+	HumidityTable    = SensorArray + ".17"
+	SwitchTable      = SensorArray + ".18"
+)
+
+const (
+	TemperatureTableEntry = TemperatureTable + ".1"
+
+	SensorTemperatureDescription  = TemperatureTableEntry + ".1"
+	SensorTemperatureLocation     = TemperatureTableEntry + ".2"
+	SensorTemperatureDegree       = TemperatureTableEntry + ".3"
+	SensorTemperatureStatus       = TemperatureTableEntry + ".4"
+	SensorTemperatureOnline       = TemperatureTableEntry + ".5"
+	SensorTemperatureGoOffline    = TemperatureTableEntry + ".6"
+	SensorTemperatureHighWarning  = TemperatureTableEntry + ".7"
+	SensorTemperatureHighCritical = TemperatureTableEntry + ".8"
+	SensorTemperatureLowWarning   = TemperatureTableEntry + ".9"
+	SensorTemperatureLowCritical  = TemperatureTableEntry + ".10"
+	SensorTemperatureRearm        = TemperatureTableEntry + ".11"
+	SensorTemperatureDegreeType   = TemperatureTableEntry + ".12"
+	SensorTemperatureDegreeRaw    = TemperatureTableEntry + ".14"
+)
+
+const (
+	HumidityTableEntry = HumidityTable + ".1"
+
+	SensorHumidityDescription  = HumidityTableEntry + ".1"
+	SensorHumidityLocation     = HumidityTableEntry + ".2"
+	SensorHumidityPercent      = HumidityTableEntry + ".3"
+	SensorHumidityStatus       = HumidityTableEntry + ".4"
+	SensorHumidityOnline       = HumidityTableEntry + ".5"
+	SensorHumidityGoOffline    = HumidityTableEntry + ".6"
+	SensorHumidityHighWarning  = HumidityTableEntry + ".7"
+	SensorHumidityHighCritical = HumidityTableEntry + ".8"
+	SensorHumidityLowWarning   = HumidityTableEntry + ".9"
+	SensorHumidityLowCritical  = HumidityTableEntry + ".10"
+	SensorHumidityRearm        = HumidityTableEntry + ".11"
+)
+
+const (
+	SwitchTableEntry = SwitchTable + ".1"
+
+	SensorSwitchDescription = SwitchTableEntry + ".1"
+	SensorSwitchLocation    = SwitchTableEntry + ".2"
+	SensorSwitchStatus      = SwitchTableEntry + ".3"
+	SensorSwitchOnline      = SwitchTableEntry + ".4"
+	SensorSwitchGoOffline   = SwitchTableEntry + ".5"
+	SensorSwitchDirection   = SwitchTableEntry + ".6"
+	SensorSwitchNormalState = SwitchTableEntry + ".7"
+	SensorSwitchOutputLevel = SwitchTableEntry + ".8"
+)
@@ -128,8 +128,13 @@ var SensorsTypes = map[string]SensorType{
 
 const (
 	PlusSeriesID = ".3"
+	System       = PlusSeriesID + ".2.1"
 	Sensors      = PlusSeriesID + ".5"
 
+	DeviceType     = System + ".8.0"
+	DeviceName     = System + ".9.0"
+	DeviceLocation = System + ".10.0"
+
 	SensorBase                  = Sensors + ".1.1"
 	SensorIdListBase            = SensorBase + ".1"
 	SensorNameBase              = SensorBase + ".2"
@@ -249,3 +254,130 @@ const (
 	SensorHumidityAcknowledge       = HumidityTableEntry + ".70"
 	SensorHumidityId                = HumidityTableEntry + ".1000"
 )
+
+const (
+	DrycontactTableEntry = DrycontactTable + ".1"
+
+	SensorDrycontactIndex        = DrycontactTableEntry + ".1"
+	SensorDrycontactDescription  = DrycontactTableEntry + ".2"
+	SensorDrycontactType         = DrycontactTableEntry + ".3"
+	SensorDrycontactStatus       = DrycontactTableEntry + ".6"
+	SensorDrycontactGoOffline    = DrycontactTableEntry + ".8"
+	SensorDrycontactNormalState  = DrycontactTableEntry + ".9"
+	SensorDrycontactDirection    = DrycontactTableEntry + ".10"
+	SensorDrycontactPort         = DrycontactTableEntry + ".35"
+	SensorDrycontactSubPort      = DrycontactTableEntry + ".36"
+	SensorDrycontactCriticalDesc = DrycontactTableEntry + ".46"
+	SensorDrycontactNormalDesc   = DrycontactTableEntry + ".48"
+	SensorDrycontactAcknowledge  = DrycontactTableEntry + ".70"
+	SensorDrycontactId           = DrycontactTableEntry + ".1000"
+)
+
+// Values of the normal state and the direction of dry contacts, the same on all devices
+const (
+	DrycontactNormalClosed = 0
+	DrycontactNormalOpen   = 1
+
+	DrycontactInput  = 0
+	DrycontactOutput = 1
+)
+
+const (
+	Current4to20mATableEntry = Current4to20mATable + ".1"
+
+	SensorCurrent4to20mAIndex        = Current4to20mATableEntry + ".1"
+	SensorCurrent4to20mADescription  = Current4to20mATableEntry + ".2"
+	SensorCurrent4to20mAType         = Current4to20mATableEntry + ".3"
+	SensorCurrent4to20mAValue        = Current4to20mATableEntry + ".4"
+	SensorCurrent4to20mAUnit         = Current4to20mATableEntry + ".5"
+	SensorCurrent4to20mAStatus       = Current4to20mATableEntry + ".6"
+	SensorCurrent4to20mAGoOffline    = Current4to20mATableEntry + ".8"
+	SensorCurrent4to20mALowCritical  = Current4to20mATableEntry + ".9"
+	SensorCurrent4to20mALowWarning   = Current4to20mATableEntry + ".10"
+	SensorCurrent4to20mAHighWarning  = Current4to20mATableEntry + ".11"
+	SensorCurrent4to20mAHighCritical = Current4to20mATableEntry + ".12"
+	SensorCurrent4to20mARaw          = Current4to20mATableEntry + ".20"
+	SensorCurrent4to20mALowValue     = Current4to20mATableEntry + ".22"
+	SensorCurrent4to20mAHighValue    = Current4to20mATableEntry + ".23"
+	SensorCurrent4to20mAPort         = Current4to20mATableEntry + ".35"
+	SensorCurrent4to20mASubPort      = Current4to20mATableEntry + ".36"
+	SensorCurrent4to20mAAcknowledge  = Current4to20mATableEntry + ".70"
+	SensorCurrent4to20mAId           = Current4to20mATableEntry + ".1000"
+)
+
+const (
+	DcVoltageTableEntry = DcVoltageTable + ".1"
+
+	SensorDcVoltageIndex        = DcVoltageTableEntry + ".1"
+	SensorDcVoltageDescription  = DcVoltageTableEntry + ".2"
+	SensorDcVoltageType         = DcVoltageTableEntry + ".3"
+	SensorDcVoltageValue        = DcVoltageTableEntry + ".4"
+	SensorDcVoltageUnit         = DcVoltageTableEntry + ".5"
+	SensorDcVoltageStatus       = DcVoltageTableEntry + ".6"
+	SensorDcVoltageGoOffline    = DcVoltageTableEntry + ".8"
+	SensorDcVoltageLowCritical  = DcVoltageTableEntry + ".9"
+	SensorDcVoltageLowWarning   = DcVoltageTableEntry + ".10"
+	SensorDcVoltageHighWarning  = DcVoltageTableEntry + ".11"
+	SensorDcVoltageHighCritical = DcVoltageTableEntry + ".12"
+	SensorDcVoltagePort         = DcVoltageTableEntry + ".35"
+	SensorDcVoltageSubPort      = DcVoltageTableEntry + ".36"
+	SensorDcVoltageAcknowledge  = DcVoltageTableEntry + ".70"
+	SensorDcVoltageId           = DcVoltageTableEntry + ".1000"
+)
+
+const (
+	AirflowTableEntry = AirflowTable + ".1"
+
+	SensorAirflowIndex        = AirflowTableEntry + ".1"
+	SensorAirflowDescription  = AirflowTableEntry + ".2"
+	SensorAirflowType         = AirflowTableEntry + ".3"
+	SensorAirflowPercent      = AirflowTableEntry + ".4"
+	SensorAirflowUnit         = AirflowTableEntry + ".5"
+	SensorAirflowStatus       = AirflowTableEntry + ".6"
+	SensorAirflowGoOffline    = AirflowTableEntry + ".8"
+	SensorAirflowLowCritical  = AirflowTableEntry + ".9"
+	SensorAirflowLowWarning   = AirflowTableEntry + ".10"
+	SensorAirflowHighWarning  = AirflowTableEntry + ".11"
+	SensorAirflowHighCritical = AirflowTableEntry + ".12"
+	SensorAirflowPort         = AirflowTableEntry + ".35"
+	SensorAirflowSubPort      = AirflowTableEntry + ".36"
+	SensorAirflowAcknowledge  = AirflowTableEntry + ".70"
+	SensorAirflowId           = AirflowTableEntry + ".1000"
+)
+
+const (
+	AcVoltageTableEntry = AcVoltageTable + ".1"
+
+	SensorAcVoltageIndex        = AcVoltageTableEntry + ".1"
+	SensorAcVoltageDescription  = AcVoltageTableEntry + ".2"
+	SensorAcVoltageType         = AcVoltageTableEntry + ".3"
+	SensorAcVoltageValue        = AcVoltageTableEntry + ".4"
+	SensorAcVoltageUnit         = AcVoltageTableEntry + ".5"
+	SensorAcVoltageStatus       = AcVoltageTableEntry + ".6"
+	SensorAcVoltageGoOffline    = AcVoltageTableEntry + ".8"
+	SensorAcVoltageLowCritical  = AcVoltageTableEntry + ".9"
+	SensorAcVoltageLowWarning   = AcVoltageTableEntry + ".10"
+	SensorAcVoltageHighWarning  = AcVoltageTableEntry + ".11"
+	SensorAcVoltageHighCritical = AcVoltageTableEntry + ".12"
+	SensorAcVoltagePort         = AcVoltageTableEntry + ".35"
+	SensorAcVoltageSubPort      = AcVoltageTableEntry + ".36"
+	SensorAcVoltageAcknowledge  = AcVoltageTableEntry + ".70"
+	SensorAcVoltageId           = AcVoltageTableEntry + ".1000"
+)
+
+const (
+	WaterRopeTableEntry = WaterRopeTable + ".1"
+
+	SensorWaterRopeIndex        = WaterRopeTableEntry + ".1"
+	SensorWaterRopeDescription  = WaterRopeTableEntry + ".2"
+	SensorWaterRopeType         = WaterRopeTableEntry + ".3"
+	SensorWaterRopeLeakLocation = WaterRopeTableEntry + ".4"
+	SensorWaterRopeUnit         = WaterRopeTableEntry + ".5"
+	SensorWaterRopeStatus       = WaterRopeTableEntry + ".6"
+	SensorWaterRopeGoOffline    = WaterRopeTableEntry + ".8"
+	SensorWaterRopeLength       = WaterRopeTableEntry + ".20"
+	SensorWaterRopePort         = WaterRopeTableEntry + ".35"
+	SensorWaterRopeSubPort      = WaterRopeTableEntry + ".36"
+	SensorWaterRopeAcknowledge  = WaterRopeTableEntry + ".70"
+	SensorWaterRopeId           = WaterRopeTableEntry + ".1000"
+)
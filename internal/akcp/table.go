@@ -0,0 +1,363 @@
+package akcp
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/utils"
+	"github.com/gosnmp/gosnmp"
+)
+
+// A Decoder transfers the value of a single cell into the sensor details
+// Numeric values are divided by the scale of the column
+type Decoder func(pdu gosnmp.SnmpPDU, scale float64, details *SensorDetails) error
+
+type Column struct {
+	OID    string
+	Decode Decoder
+	// Some values are off by a factor (e.g. 10 to fake decimal point numbers), 0 means 1
+	Scale float64
+	// A sensor can not be checked without this column (e.g. its value or status),
+	// the other columns are informational and may be missing on some firmware
+	Required bool
+}
+
+// Declaration of a sensor table, every row of the table is a sensor
+type Table struct {
+	OID     string
+	Columns []Column
+	// Defaults for tables which only contain a single kind of sensor
+	SensorType uint64
+	Unit       string
+}
+
+// Walks the whole table and returns one entry per row, ordered by the row index
+// Rows which can not be decoded are returned as well, with Err set
+func ReadTable(params Client, table Table) ([]SensorDetails, error) {
+	oid := akcpBaseOID + table.OID
+
+	pdus, err := walk(params, oid)
+	if err != nil {
+		return nil, err
+	}
+
+	// The column ID follows the entry OID (table + ".1")
+	prefixLength := uint(strings.Count(oid+".1", ".") + 1)
+
+	rows, err := utils.ParseSnmpTable(&pdus, prefixLength)
+	if err != nil {
+		return nil, err
+	}
+
+	sensors := make([]SensorDetails, 0, len(*rows))
+
+	for _, rowID := range utils.SortedRowIDs(*rows) {
+		details, err := table.decodeRow(rowID, (*rows)[rowID])
+		if err != nil {
+			// Only this sensor is affected, the others can still be checked
+			details.Err = err
+		}
+
+		sensors = append(sensors, details)
+	}
+
+	return sensors, nil
+}
+
+// Decodes the cells of a single row, cells of undeclared columns are ignored
+// A cell which can not be decoded does not stop the decoding of the others, the errors are joined
+func (t Table) decodeRow(rowID string, cells []utils.Cell) (SensorDetails, error) {
+	details := t.newSensorDetails(rowID)
+
+	var errs []error
+
+	for _, column := range t.Columns {
+		found := false
+
+		for _, cell := range cells {
+			if column.OID[strings.LastIndex(column.OID, ".")+1:] != cell.ID {
+				continue
+			}
+
+			found = true
+
+			errs = append(errs, decodeCell(column, cell.Pdu, &details))
+		}
+
+		if column.Required && !found {
+			errs = append(errs, missingCell(column, rowID))
+		}
+	}
+
+	return details, errors.Join(errs...)
+}
+
+// Decodes a single cell, the error names the cell
+func decodeCell(column Column, pdu gosnmp.SnmpPDU, details *SensorDetails) error {
+	err := column.Decode(pdu, column.scale(), details)
+
+	var akcpErr *Error
+	if err == nil || errors.As(err, &akcpErr) {
+		return err
+	}
+
+	return &Error{Kind: ErrDecode, OID: pdu.Name, Err: err}
+}
+
+// Walks only the declared columns of the table, one after another, and joins the cells by
+// their row index. Cheaper than ReadTable for wide tables of which only a few columns are needed.
+// Rows are returned in the order of their index, the columns are decoded in the order of their declaration.
+// Rows which lack a required column are returned with Err set, instead of a default (e.g. a value of 0).
+func ReadTableColumns(params Client, table Table) ([]SensorDetails, error) {
+	columns := make([][]gosnmp.SnmpPDU, len(table.Columns))
+
+	err := forEachConcurrently(len(table.Columns), concurrency(params), func(i int) error {
+		var err error
+
+		columns[i], err = walk(params, akcpBaseOID+table.Columns[i].OID)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(map[string]*SensorDetails)
+	// The required columns found for each row
+	present := make(map[string][]bool)
+
+	for i, column := range table.Columns {
+		oid := akcpBaseOID + column.OID
+
+		for _, pdu := range columns[i] {
+			rowID, found := strings.CutPrefix("."+strings.TrimPrefix(pdu.Name, "."), oid+".")
+			if !found {
+				continue
+			}
+
+			details, ok := rows[rowID]
+			if !ok {
+				tmp := table.newSensorDetails(rowID)
+				details = &tmp
+				rows[rowID] = details
+				present[rowID] = make([]bool, len(table.Columns))
+			}
+
+			present[rowID][i] = true
+
+			err = decodeCell(column, pdu, details)
+			if err != nil {
+				details.Err = errors.Join(details.Err, err)
+			}
+		}
+	}
+
+	rowIDs := make([]string, 0, len(rows))
+	for rowID, details := range rows {
+		for i, column := range table.Columns {
+			if column.Required && !present[rowID][i] {
+				details.Err = errors.Join(details.Err, missingCell(column, rowID))
+			}
+		}
+
+		rowIDs = append(rowIDs, rowID)
+	}
+
+	sort.Slice(rowIDs, func(i, j int) bool {
+		return utils.CompareOIDs(rowIDs[i], rowIDs[j]) < 0
+	})
+
+	sensors := make([]SensorDetails, 0, len(rows))
+	for _, rowID := range rowIDs {
+		sensors = append(sensors, *rows[rowID])
+	}
+
+	return sensors, nil
+}
+
+// Fetches a single row of the table
+// Only failures of the request are returned as error, values which can not be decoded are reported in Err
+func ReadTableRow(params Client, table Table, index string) (SensorDetails, error) {
+	details := table.newSensorDetails(index)
+
+	oids := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		oids[i] = akcpBaseOID + column.OID + "." + index
+	}
+
+	query, err := get(params, oids)
+	if err != nil {
+		return details, err
+	}
+
+	var errs []error
+
+	for i, column := range table.Columns {
+		errs = append(errs, decodeCell(column, query.Variables[i], &details))
+	}
+
+	// The sensor could be queried, the failure is specific to it
+	details.Err = errors.Join(errs...)
+
+	return details, nil
+}
+
+func missingCell(column Column, rowID string) error {
+	return &Error{Kind: ErrNoSuchInstance, OID: akcpBaseOID + column.OID + "." + rowID}
+}
+
+func (t Table) newSensorDetails(index string) SensorDetails {
+	return SensorDetails{
+		Index:      index,
+		SensorType: t.SensorType,
+		Unit:       t.Unit,
+	}
+}
+
+func (c Column) scale() float64 {
+	if c.Scale == 0 {
+		return 1
+	}
+
+	return c.Scale
+}
+
+func DecodeName(pdu gosnmp.SnmpPDU, _ float64, details *SensorDetails) error {
+	details.Name = ValueToString(pdu)
+
+	return nil
+}
+
+// An empty unit keeps the default unit of the table
+func DecodeUnit(pdu gosnmp.SnmpPDU, _ float64, details *SensorDetails) error {
+	if unit := ValueToString(pdu); unit != "" {
+		details.Unit = unit
+	}
+
+	return nil
+}
+
+func DecodeSensorType(pdu gosnmp.SnmpPDU, _ float64, details *SensorDetails) error {
+	tmp, err := ValueToUint64(pdu)
+	if err != nil {
+		return err
+	}
+
+	details.SensorType = tmp
+
+	return nil
+}
+
+func DecodeStatus(pdu gosnmp.SnmpPDU, _ float64, details *SensorDetails) error {
+	tmp, err := ValueToUint64(pdu)
+	if err != nil {
+		return err
+	}
+
+	details.Status = SensorStatus(tmp)
+
+	return nil
+}
+
+// For sensors without a value (e.g. dry contacts), the value is 1 if they are not in their normal state
+func DecodeStatusWithValue(pdu gosnmp.SnmpPDU, scale float64, details *SensorDetails) error {
+	err := DecodeStatus(pdu, scale, details)
+	if err != nil {
+		return err
+	}
+
+	if details.Status != Normal {
+		details.Value = 1
+	} else {
+		details.Value = 0
+	}
+
+	return nil
+}
+
+// Values of the common table of the sensorProbe+ are given as float
+func DecodeFloatValue(pdu gosnmp.SnmpPDU, scale float64, details *SensorDetails) error {
+	tmp, err := ValueIEEE754ToFloat64(pdu)
+	if err != nil {
+		return err
+	}
+
+	details.Value = tmp / scale
+
+	return nil
+}
+
+// The description of the current state of the sensor (e.g. "Motion detected"),
+// the on and off descriptions have to be declared after the status column
+func DecodeOnDescription(pdu gosnmp.SnmpPDU, _ float64, details *SensorDetails) error {
+	if details.Status != Normal {
+		details.Description = ValueToString(pdu)
+	}
+
+	return nil
+}
+
+func DecodeOffDescription(pdu gosnmp.SnmpPDU, _ float64, details *SensorDetails) error {
+	if details.Status == Normal {
+		details.Description = ValueToString(pdu)
+	}
+
+	return nil
+}
+
+func DecodeAcknowledged(pdu gosnmp.SnmpPDU, _ float64, details *SensorDetails) error {
+	tmp, err := ValueToUint64(pdu)
+	if err != nil {
+		return err
+	}
+
+	details.Acknowledged = tmp == 1
+
+	return nil
+}
+
+// Values and thresholds are signed, e.g. for temperatures below 0 °C
+func DecodeValue(pdu gosnmp.SnmpPDU, scale float64, details *SensorDetails) error {
+	tmp, err := ValueToInt64(pdu)
+	if err != nil {
+		return err
+	}
+
+	details.Value = float64(tmp) / scale
+
+	return nil
+}
+
+func DecodeLowWarning(pdu gosnmp.SnmpPDU, scale float64, details *SensorDetails) error {
+	return decodeThreshold(pdu, scale, &details.Warning, false)
+}
+
+func DecodeHighWarning(pdu gosnmp.SnmpPDU, scale float64, details *SensorDetails) error {
+	return decodeThreshold(pdu, scale, &details.Warning, true)
+}
+
+func DecodeLowCritical(pdu gosnmp.SnmpPDU, scale float64, details *SensorDetails) error {
+	return decodeThreshold(pdu, scale, &details.Critical, false)
+}
+
+func DecodeHighCritical(pdu gosnmp.SnmpPDU, scale float64, details *SensorDetails) error {
+	return decodeThreshold(pdu, scale, &details.Critical, true)
+}
+
+func decodeThreshold(pdu gosnmp.SnmpPDU, scale float64, threshold *MayThreshold, upper bool) error {
+	tmp, err := ValueToInt64(pdu)
+	if err != nil {
+		return err
+	}
+
+	if upper {
+		threshold.Val.Upper = float64(tmp) / scale
+	} else {
+		threshold.Val.Lower = float64(tmp) / scale
+	}
+
+	threshold.Present = true
+
+	return nil
+}
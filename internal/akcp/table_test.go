@@ -0,0 +1,180 @@
+package akcp
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/utils"
+	"github.com/gosnmp/gosnmp"
+)
+
+func integerCell(column string, value int) utils.Cell {
+	return utils.Cell{
+		ID:  column[len(sensorProbePlus.TemperatureTableEntry)+1:],
+		Pdu: gosnmp.SnmpPDU{Name: akcpBaseOID + column + ".1.1.1.1", Type: gosnmp.Integer, Value: value},
+	}
+}
+
+func TestDecodeNegativeTemperature(t *testing.T) {
+	cells := []utils.Cell{
+		integerCell(sensorProbePlus.SensorTemperatureDegree, -78),
+		integerCell(sensorProbePlus.SensorTemperatureStatus, int(Normal)),
+		integerCell(sensorProbePlus.SensorTemperatureLowCritical, -850),
+		integerCell(sensorProbePlus.SensorTemperatureLowWarning, -820),
+		integerCell(sensorProbePlus.SensorTemperatureHighWarning, -650),
+		integerCell(sensorProbePlus.SensorTemperatureHighCritical, -600),
+	}
+
+	details, err := plusTemperatureTable.decodeRow("1.1.1.1", cells)
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	if details.Index != "1.1.1.1" {
+		t.Error("\nActual: ", details.Index, "\nExpected: 1.1.1.1")
+	}
+
+	if details.Value != -78 {
+		t.Error("\nActual: ", details.Value, "\nExpected: -78")
+	}
+
+	if details.Critical.Val.Lower != -85 || details.Critical.Val.Upper != -60 {
+		t.Error("\nActual: ", details.Critical.Val, "\nExpected: -85:-60")
+	}
+
+	if details.Warning.Val.Lower != -82 || details.Warning.Val.Upper != -65 {
+		t.Error("\nActual: ", details.Warning.Val, "\nExpected: -82:-65")
+	}
+
+	if details.Warning.Val.DoesViolate(details.Value) {
+		t.Error("Expected -78 to be inside of the warning thresholds")
+	}
+}
+
+func TestValueToInt64(t *testing.T) {
+	testcases := map[string]struct {
+		pdu         gosnmp.SnmpPDU
+		expected    int64
+		expectError bool
+	}{
+		"negative": {
+			pdu:      gosnmp.SnmpPDU{Type: gosnmp.Integer, Value: -20},
+			expected: -20,
+		},
+		"positive": {
+			pdu:      gosnmp.SnmpPDU{Type: gosnmp.Integer, Value: 42},
+			expected: 42,
+		},
+		"notAnInteger": {
+			pdu:         gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte("-20")},
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := ValueToInt64(tc.pdu)
+
+			if tc.expectError {
+				if err == nil {
+					t.Error("Expected an error, got nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Error("Expected no error, got ", err)
+			}
+
+			if actual != tc.expected {
+				t.Error("\nActual: ", actual, "\nExpected: ", tc.expected)
+			}
+		})
+	}
+}
+
+func TestValueIEEE754ToFloat64Negative(t *testing.T) {
+	raw := make([]byte, 4)
+	binary.LittleEndian.PutUint32(raw, math.Float32bits(-79.5))
+
+	testcases := map[string]gosnmp.SnmpPDU{
+		"opaque":      {Type: gosnmp.Opaque, Value: raw},
+		"opaqueFloat": {Type: gosnmp.OpaqueFloat, Value: float32(-79.5)},
+	}
+
+	for name, pdu := range testcases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := ValueIEEE754ToFloat64(pdu)
+			if err != nil {
+				t.Error("Expected no error, got ", err)
+			}
+
+			if actual != -79.5 {
+				t.Error("\nActual: ", actual, "\nExpected: -79.5")
+			}
+		})
+	}
+}
+
+func TestReadTableColumnsMissingCell(t *testing.T) {
+	testcases := map[string]struct {
+		column      string
+		expectedErr bool
+	}{
+		"value": {
+			column:      sensorProbePlus.SensorsValueFormatFloatBase,
+			expectedErr: true,
+		},
+		// Not exposed by every firmware, the sensor can still be checked
+		"onDescription": {
+			column:      sensorProbePlus.SensorsOnDescriptionBase,
+			expectedErr: false,
+		},
+	}
+
+	walk, err := os.ReadFile("../../testdata/sensorProbePlus.walk")
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// The cell of the first temperature sensor is missing
+			missing := akcpBaseOID + tc.column + ".1.1.1.1 "
+
+			lines := strings.Split(string(walk), "\n")
+			kept := make([]string, 0, len(lines))
+
+			for _, line := range lines {
+				if !strings.HasPrefix(line, missing) {
+					kept = append(kept, line)
+				}
+			}
+
+			if len(kept) != len(lines)-1 {
+				t.Fatal("Expected the cell of the sensor in the walk")
+			}
+
+			sensors, err := ReadTableColumns(parseReplay(t, strings.Join(kept, "\n")), plusSensorTable)
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+
+			for _, sensor := range sensors {
+				if sensor.Index == "1.1.1.1" && tc.expectedErr {
+					if !errors.Is(sensor.Err, ErrNoSuchInstance) {
+						t.Error("\nActual: ", sensor.Err, "\nExpected: ", ErrNoSuchInstance)
+					}
+				} else if sensor.Err != nil {
+					t.Error("Expected no error for ", sensor.Name, ", got ", sensor.Err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package akcp
+
+import (
+	"slices"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+	"github.com/NETWAYS/go-check"
+)
+
+// Sensor tables of the sensorProbe+
+
+// The common table contains all sensors, but not their thresholds
+var plusSensorTable = Table{
+	OID: sensorProbePlus.CommonTable,
+	Columns: []Column{
+		{OID: sensorProbePlus.SensorNameBase, Decode: DecodeName},
+		{OID: sensorProbePlus.SensorTypeBase, Decode: DecodeSensorType},
+		{OID: sensorProbePlus.SensorUnitBase, Decode: DecodeUnit},
+		{OID: sensorProbePlus.SensorStatusBase, Decode: DecodeStatus, Required: true},
+		{OID: sensorProbePlus.SensorsOnDescriptionBase, Decode: DecodeOnDescription},
+		{OID: sensorProbePlus.SensorsOffDescriptionBase, Decode: DecodeOffDescription},
+		{OID: sensorProbePlus.SensorsValueFormatFloatBase, Decode: DecodeFloatValue, Required: true},
+	},
+}
+
+var plusTemperatureTable = Table{
+	OID: sensorProbePlus.TemperatureTable,
+	Columns: []Column{
+		{OID: sensorProbePlus.SensorTemperatureDescription, Decode: DecodeName},
+		{OID: sensorProbePlus.SensorTemperatureType, Decode: DecodeSensorType},
+		{OID: sensorProbePlus.SensorTemperatureDegree, Decode: DecodeValue},
+		{OID: sensorProbePlus.SensorTemperatureUnit, Decode: DecodeUnit},
+		{OID: sensorProbePlus.SensorTemperatureStatus, Decode: DecodeStatus},
+		// Thresholds are off by a factor of 10 to fake decimal point numbers
+		{OID: sensorProbePlus.SensorTemperatureLowCritical, Decode: DecodeLowCritical, Scale: 10},
+		{OID: sensorProbePlus.SensorTemperatureLowWarning, Decode: DecodeLowWarning, Scale: 10},
+		{OID: sensorProbePlus.SensorTemperatureHighWarning, Decode: DecodeHighWarning, Scale: 10},
+		{OID: sensorProbePlus.SensorTemperatureHighCritical, Decode: DecodeHighCritical, Scale: 10},
+		{OID: sensorProbePlus.SensorTemperatureAcknowledge, Decode: DecodeAcknowledged},
+	},
+}
+
+var plusHumidityTable = Table{
+	OID: sensorProbePlus.HumidityTable,
+	Columns: []Column{
+		{OID: sensorProbePlus.SensorHumidityDescription, Decode: DecodeName},
+		{OID: sensorProbePlus.SensorHumidityType, Decode: DecodeSensorType},
+		{OID: sensorProbePlus.SensorHumidityPercent, Decode: DecodeValue},
+		{OID: sensorProbePlus.SensorHumidityUnit, Decode: DecodeUnit},
+		{OID: sensorProbePlus.SensorHumidityStatus, Decode: DecodeStatus},
+		{OID: sensorProbePlus.SensorHumidityLowCritical, Decode: DecodeLowCritical},
+		{OID: sensorProbePlus.SensorHumidityLowWarning, Decode: DecodeLowWarning},
+		{OID: sensorProbePlus.SensorHumidityHighWarning, Decode: DecodeHighWarning},
+		{OID: sensorProbePlus.SensorHumidityHighCritical, Decode: DecodeHighCritical},
+		{OID: sensorProbePlus.SensorHumidityAcknowledge, Decode: DecodeAcknowledged},
+	},
+}
+
+var plusDrycontactTable = Table{
+	OID: sensorProbePlus.DrycontactTable,
+	Columns: []Column{
+		{OID: sensorProbePlus.SensorDrycontactDescription, Decode: DecodeName},
+		{OID: sensorProbePlus.SensorDrycontactType, Decode: DecodeSensorType},
+		{OID: sensorProbePlus.SensorDrycontactStatus, Decode: DecodeStatusWithValue},
+		{OID: sensorProbePlus.SensorDrycontactNormalState, Decode: DecodeContactNormalState},
+		{OID: sensorProbePlus.SensorDrycontactDirection, Decode: DecodeContactDirection},
+		{OID: sensorProbePlus.SensorDrycontactAcknowledge, Decode: DecodeAcknowledged},
+	},
+}
+
+var plusCurrent4to20mATable = Table{
+	OID:        sensorProbePlus.Current4to20mATable,
+	SensorType: sensorProbePlus.Four_20mA,
+	Columns: []Column{
+		{OID: sensorProbePlus.SensorCurrent4to20mADescription, Decode: DecodeName},
+		{OID: sensorProbePlus.SensorCurrent4to20mAUnit, Decode: DecodeUnit},
+		{OID: sensorProbePlus.SensorCurrent4to20mAStatus, Decode: DecodeStatus},
+		{OID: sensorProbePlus.SensorCurrent4to20mALowCritical, Decode: DecodeLowCritical},
+		{OID: sensorProbePlus.SensorCurrent4to20mALowWarning, Decode: DecodeLowWarning},
+		{OID: sensorProbePlus.SensorCurrent4to20mAHighWarning, Decode: DecodeHighWarning},
+		{OID: sensorProbePlus.SensorCurrent4to20mAHighCritical, Decode: DecodeHighCritical},
+		{OID: sensorProbePlus.SensorCurrent4to20mALowValue, Decode: DecodeLoopRangeLow},
+		{OID: sensorProbePlus.SensorCurrent4to20mAHighValue, Decode: DecodeLoopRangeHigh},
+		{OID: sensorProbePlus.SensorCurrent4to20mAAcknowledge, Decode: DecodeAcknowledged},
+	},
+}
+
+var plusDcVoltageTable = Table{
+	OID:        sensorProbePlus.DcVoltageTable,
+	SensorType: sensorProbePlus.Dcvoltage,
+	Unit:       "V",
+	Columns: []Column{
+		{OID: sensorProbePlus.SensorDcVoltageDescription, Decode: DecodeName},
+		{OID: sensorProbePlus.SensorDcVoltageValue, Decode: DecodeValue},
+		{OID: sensorProbePlus.SensorDcVoltageUnit, Decode: DecodeUnit},
+		{OID: sensorProbePlus.SensorDcVoltageStatus, Decode: DecodeStatus},
+		{OID: sensorProbePlus.SensorDcVoltageLowCritical, Decode: DecodeLowCritical},
+		{OID: sensorProbePlus.SensorDcVoltageLowWarning, Decode: DecodeLowWarning},
+		{OID: sensorProbePlus.SensorDcVoltageHighWarning, Decode: DecodeHighWarning},
+		{OID: sensorProbePlus.SensorDcVoltageHighCritical, Decode: DecodeHighCritical},
+		{OID: sensorProbePlus.SensorDcVoltageAcknowledge, Decode: DecodeAcknowledged},
+	},
+}
+
+// Only the low thresholds of an airflow sensor are read, a high airflow is no problem
+var plusAirflowTable = Table{
+	OID:        sensorProbePlus.AirflowTable,
+	SensorType: sensorProbePlus.Airflow,
+	Unit:       "%",
+	Columns: []Column{
+		{OID: sensorProbePlus.SensorAirflowDescription, Decode: DecodeName},
+		{OID: sensorProbePlus.SensorAirflowPercent, Decode: DecodeValue},
+		{OID: sensorProbePlus.SensorAirflowUnit, Decode: DecodeUnit},
+		{OID: sensorProbePlus.SensorAirflowStatus, Decode: DecodeStatus},
+		{OID: sensorProbePlus.SensorAirflowLowCritical, Decode: DecodeLowCritical},
+		{OID: sensorProbePlus.SensorAirflowLowWarning, Decode: DecodeLowWarning},
+		{OID: sensorProbePlus.SensorAirflowAcknowledge, Decode: DecodeAcknowledged},
+	},
+}
+
+var plusAcVoltageTable = Table{
+	OID:        sensorProbePlus.AcVoltageTable,
+	SensorType: sensorProbePlus.Acvoltage,
+	Unit:       "V",
+	Columns: []Column{
+		{OID: sensorProbePlus.SensorAcVoltageDescription, Decode: DecodeName},
+		{OID: sensorProbePlus.SensorAcVoltageValue, Decode: DecodeValue},
+		{OID: sensorProbePlus.SensorAcVoltageUnit, Decode: DecodeUnit},
+		{OID: sensorProbePlus.SensorAcVoltageStatus, Decode: DecodeStatus},
+		{OID: sensorProbePlus.SensorAcVoltageLowCritical, Decode: DecodeLowCritical},
+		{OID: sensorProbePlus.SensorAcVoltageLowWarning, Decode: DecodeLowWarning},
+		{OID: sensorProbePlus.SensorAcVoltageHighWarning, Decode: DecodeHighWarning},
+		{OID: sensorProbePlus.SensorAcVoltageHighCritical, Decode: DecodeHighCritical},
+		{OID: sensorProbePlus.SensorAcVoltageAcknowledge, Decode: DecodeAcknowledged},
+	},
+}
+
+// The distance of a leak and the length of the rope are given in the unit of the rope
+var plusWaterRopeTable = Table{
+	OID:        sensorProbePlus.WaterRopeTable,
+	SensorType: sensorProbePlus.Waterrope,
+	Unit:       "m",
+	Columns: []Column{
+		{OID: sensorProbePlus.SensorWaterRopeDescription, Decode: DecodeName},
+		{OID: sensorProbePlus.SensorWaterRopeLeakLocation, Decode: DecodeValue, Required: true},
+		{OID: sensorProbePlus.SensorWaterRopeUnit, Decode: DecodeUnit},
+		{OID: sensorProbePlus.SensorWaterRopeStatus, Decode: DecodeStatus},
+		{OID: sensorProbePlus.SensorWaterRopeLength, Decode: DecodeRopeLength},
+		{OID: sensorProbePlus.SensorWaterRopeAcknowledge, Decode: DecodeAcknowledged},
+	},
+}
+
+// A table of a single kind of sensor, which holds details missing in the common table
+type typeTable struct {
+	Table
+	// The sensor types found in the table
+	types []uint64
+	// Copies the details of the row into the sensor of the common table
+	merge func(sensor *SensorDetails, row SensorDetails)
+}
+
+var plusTypeTables = []typeTable{
+	{
+		// Both temperature types share a table
+		Table: plusTemperatureTable,
+		types: []uint64{sensorProbePlus.Temperature, sensorProbePlus.Temperature_dual},
+		merge: mergeThresholds,
+	},
+	{
+		Table: plusHumidityTable,
+		types: []uint64{sensorProbePlus.Humidity_dual},
+		merge: mergeThresholds,
+	},
+	{
+		Table: plusDrycontactTable,
+		types: []uint64{sensorProbePlus.Dry_inout, sensorProbePlus.Dry_in},
+		merge: mergeContact,
+	},
+	{
+		Table: plusCurrent4to20mATable,
+		types: []uint64{sensorProbePlus.Four_20mA},
+		merge: mergeCurrentLoop,
+	},
+	{
+		Table: plusDcVoltageTable,
+		types: []uint64{sensorProbePlus.Dcvoltage},
+		merge: mergeThresholdsAndUnit,
+	},
+	{
+		Table: plusAirflowTable,
+		types: []uint64{sensorProbePlus.Airflow},
+		merge: mergeLowThresholds,
+	},
+	{
+		Table: plusAcVoltageTable,
+		types: []uint64{sensorProbePlus.Acvoltage},
+		merge: mergeThresholdsAndUnit,
+	},
+	{
+		Table: plusWaterRopeTable,
+		types: []uint64{sensorProbePlus.Waterrope},
+		merge: mergeWaterRope,
+	},
+}
+
+func mergeThresholds(sensor *SensorDetails, row SensorDetails) {
+	sensor.Warning = row.Warning
+	sensor.Critical = row.Critical
+}
+
+// The thresholds have no upper bound, only a value below them is a problem
+func mergeLowThresholds(sensor *SensorDetails, row SensorDetails) {
+	mergeThresholds(sensor, row)
+
+	if sensor.Warning.Present {
+		sensor.Warning.Val.Upper = check.PosInf
+	}
+
+	if sensor.Critical.Present {
+		sensor.Critical.Val.Upper = check.PosInf
+	}
+}
+
+func mergeContact(sensor *SensorDetails, row SensorDetails) {
+	sensor.Direction = row.Direction
+	sensor.NormalState = row.NormalState
+}
+
+// The unit of the table is used, if the common table does not give one
+func mergeThresholdsAndUnit(sensor *SensorDetails, row SensorDetails) {
+	mergeThresholds(sensor, row)
+
+	if sensor.Unit == "" {
+		sensor.Unit = row.Unit
+	}
+}
+
+// The value of the common table is already given in engineering units
+func mergeCurrentLoop(sensor *SensorDetails, row SensorDetails) {
+	mergeThresholdsAndUnit(sensor, row)
+
+	sensor.LoopRange = row.LoopRange
+}
+
+// The location of the leak and the length are given in the unit of the rope
+func mergeWaterRope(sensor *SensorDetails, row SensorDetails) {
+	sensor.Value = row.Value
+	sensor.Unit = row.Unit
+	sensor.RopeLength = row.RopeLength
+}
+
+// Returns the index of the table holding the details of the sensor type, -1 if there is none
+func typeTableOf(sensorType uint64) int {
+	return slices.IndexFunc(plusTypeTables, func(t typeTable) bool {
+		return slices.Contains(t.types, sensorType)
+	})
+}
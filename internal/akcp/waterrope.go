@@ -0,0 +1,73 @@
+package akcp
+
+import (
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+	"github.com/gosnmp/gosnmp"
+)
+
+// The state of a water rope
+type RopeState int
+
+const (
+	RopeStateUnknown RopeState = iota
+	RopeDry
+	RopeLeak
+	RopeBroken
+)
+
+func (s RopeState) String() string {
+	switch s {
+	case RopeDry:
+		return "no leak"
+	case RopeLeak:
+		return "leak"
+	case RopeBroken:
+		return "rope broken"
+	default:
+		return "unknown"
+	}
+}
+
+// Returns whether the sensor is a water rope
+func IsWaterRope(sensorType uint64) bool {
+	return sensorType == sensorProbePlus.Waterrope
+}
+
+// The state of a water rope, the device reports a broken rope as a sensor error
+func (s SensorDetails) RopeState() RopeState {
+	switch s.Status {
+	case Normal:
+		return RopeDry
+	case HighWarning, HighCritical, LowWarning, LowCritical:
+		return RopeLeak
+	case SensorError:
+		return RopeBroken
+	default:
+		return RopeStateUnknown
+	}
+}
+
+// Returns the distance of a leak from the start of the rope, in the unit of the rope.
+// A location beyond the configured length of the rope is not a valid distance.
+func (s SensorDetails) LeakDistance() (float64, bool) {
+	if s.RopeState() != RopeLeak || s.Value < 0 {
+		return 0, false
+	}
+
+	if s.RopeLength > 0 && s.Value > s.RopeLength {
+		return 0, false
+	}
+
+	return s.Value, true
+}
+
+func DecodeRopeLength(pdu gosnmp.SnmpPDU, scale float64, details *SensorDetails) error {
+	tmp, err := ValueToInt64(pdu)
+	if err != nil {
+		return err
+	}
+
+	details.RopeLength = float64(tmp) / scale
+
+	return nil
+}
@@ -0,0 +1,280 @@
+package capture
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// A single PDU in a capture, the types and value representations follow the
+// output of snmpwalk (e.g. "INTEGER", "STRING", "Hex-STRING", "Opaque")
+type PDU struct {
+	OID   string `json:"oid"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// The JSON capture format
+type File struct {
+	Version int    `json:"version"`
+	Created string `json:"created,omitempty"`
+	Device  string `json:"device,omitempty"`
+	Mode    string `json:"mode,omitempty"`
+	Comment string `json:"comment,omitempty"`
+	PDUs    []PDU  `json:"pdus"`
+}
+
+const FileVersion = 1
+
+// Enumerations are printed with their name if the MIB is available, e.g. "normal(2)"
+var enumRe = regexp.MustCompile(`^[A-Za-z][\w-]*\((-?\d+)\)$`)
+
+// Converts a PDU in its textual representation into a gosnmp PDU
+// nolint: gocyclo
+func (p PDU) ToSnmpPDU() (gosnmp.SnmpPDU, error) {
+	pdu := gosnmp.SnmpPDU{Name: normalizeOID(p.OID)}
+	value := strings.TrimSpace(p.Value)
+
+	switch p.Type {
+	case "INTEGER":
+		if match := enumRe.FindStringSubmatch(value); match != nil {
+			value = match[1]
+		}
+
+		tmp, err := strconv.Atoi(value)
+		if err != nil {
+			return pdu, fmt.Errorf("invalid INTEGER %s for %s: %w", value, p.OID, err)
+		}
+
+		pdu.Type = gosnmp.Integer
+		pdu.Value = tmp
+	case "STRING":
+		pdu.Type = gosnmp.OctetString
+		pdu.Value = []byte(p.Value)
+	case "Hex-STRING", "Opaque":
+		tmp, err := hex.DecodeString(strings.Join(strings.Fields(value), ""))
+		if err != nil {
+			return pdu, fmt.Errorf("invalid %s %s for %s: %w", p.Type, value, p.OID, err)
+		}
+
+		pdu.Type = gosnmp.OctetString
+		if p.Type == "Opaque" {
+			pdu.Type = gosnmp.Opaque
+		}
+
+		pdu.Value = tmp
+	case "Float", "Double":
+		tmp, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return pdu, fmt.Errorf("invalid %s %s for %s: %w", p.Type, value, p.OID, err)
+		}
+
+		if p.Type == "Float" {
+			pdu.Type = gosnmp.OpaqueFloat
+			pdu.Value = float32(tmp)
+		} else {
+			pdu.Type = gosnmp.OpaqueDouble
+			pdu.Value = tmp
+		}
+	case "OID":
+		pdu.Type = gosnmp.ObjectIdentifier
+		pdu.Value = normalizeOID(value)
+	case "IpAddress":
+		pdu.Type = gosnmp.IPAddress
+		pdu.Value = value
+	case "Counter32", "Gauge32":
+		tmp, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return pdu, fmt.Errorf("invalid %s %s for %s: %w", p.Type, value, p.OID, err)
+		}
+
+		pdu.Type = gosnmp.Counter32
+		if p.Type == "Gauge32" {
+			pdu.Type = gosnmp.Gauge32
+		}
+
+		pdu.Value = uint(tmp)
+	case "Counter64":
+		tmp, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return pdu, fmt.Errorf("invalid Counter64 %s for %s: %w", value, p.OID, err)
+		}
+
+		pdu.Type = gosnmp.Counter64
+		pdu.Value = tmp
+	case "Timeticks":
+		// snmpwalk prints "(12345) 0:02:03.45"
+		value = strings.TrimPrefix(strings.Fields(value + " ")[0], "(")
+		value = strings.TrimSuffix(value, ")")
+
+		tmp, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return pdu, fmt.Errorf("invalid Timeticks %s for %s: %w", value, p.OID, err)
+		}
+
+		pdu.Type = gosnmp.TimeTicks
+		pdu.Value = uint32(tmp)
+	case "NULL":
+		pdu.Type = gosnmp.Null
+	case "NoSuchObject":
+		pdu.Type = gosnmp.NoSuchObject
+	case "NoSuchInstance":
+		pdu.Type = gosnmp.NoSuchInstance
+	default:
+		return pdu, fmt.Errorf("unsupported type %s for %s", p.Type, p.OID)
+	}
+
+	return pdu, nil
+}
+
+// Converts a gosnmp PDU into its textual representation
+func FromSnmpPDU(pdu gosnmp.SnmpPDU) (PDU, error) {
+	result := PDU{OID: normalizeOID(pdu.Name)}
+
+	switch pdu.Type { //nolint: exhaustive
+	case gosnmp.Integer:
+		result.Type = "INTEGER"
+		result.Value = gosnmp.ToBigInt(pdu.Value).String()
+	case gosnmp.OctetString:
+		tmp := pdu.Value.([]byte)
+		if utf8.Valid(tmp) {
+			result.Type = "STRING"
+			result.Value = string(tmp)
+		} else {
+			result.Type = "Hex-STRING"
+			result.Value = hexString(tmp)
+		}
+	case gosnmp.Opaque:
+		result.Type = "Opaque"
+		result.Value = hexString(pdu.Value.([]byte))
+	case gosnmp.OpaqueFloat:
+		result.Type = "Float"
+		result.Value = strconv.FormatFloat(float64(pdu.Value.(float32)), 'f', -1, 32)
+	case gosnmp.OpaqueDouble:
+		result.Type = "Double"
+		result.Value = strconv.FormatFloat(pdu.Value.(float64), 'f', -1, 64)
+	case gosnmp.ObjectIdentifier:
+		result.Type = "OID"
+		result.Value = normalizeOID(pdu.Value.(string))
+	case gosnmp.IPAddress:
+		result.Type = "IpAddress"
+		result.Value = fmt.Sprint(pdu.Value)
+	case gosnmp.Counter32, gosnmp.Gauge32, gosnmp.Counter64, gosnmp.TimeTicks, gosnmp.Uinteger32:
+		result.Type = map[gosnmp.Asn1BER]string{
+			gosnmp.Counter32:  "Counter32",
+			gosnmp.Gauge32:    "Gauge32",
+			gosnmp.Counter64:  "Counter64",
+			gosnmp.TimeTicks:  "Timeticks",
+			gosnmp.Uinteger32: "Gauge32",
+		}[pdu.Type]
+		result.Value = gosnmp.ToBigInt(pdu.Value).String()
+	case gosnmp.Null:
+		result.Type = "NULL"
+	case gosnmp.NoSuchObject:
+		result.Type = "NoSuchObject"
+	case gosnmp.NoSuchInstance:
+		result.Type = "NoSuchInstance"
+	default:
+		return result, fmt.Errorf("unsupported type %s for %s", pdu.Type, pdu.Name)
+	}
+
+	return result, nil
+}
+
+// Parses the output of "snmpwalk -On"
+// Lines look like ".1.3.6.1.2.1.1.5.0 = STRING: "name"", strings may span multiple lines
+func ParseWalk(text string) ([]PDU, error) {
+	var pdus []PDU
+
+	for number, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		oid, rest, found := strings.Cut(line, " = ")
+		if !found || !strings.HasPrefix(strings.TrimSpace(oid), ".") {
+			// continuation of a multi line string
+			if len(pdus) > 0 && pdus[len(pdus)-1].Type == "STRING" && line != "" {
+				pdus[len(pdus)-1].Value += "\n" + line
+				continue
+			}
+
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			return nil, fmt.Errorf("line %d: not in snmpwalk -On format", number+1)
+		}
+
+		pdu := PDU{OID: strings.TrimSpace(oid)}
+
+		switch {
+		case strings.HasPrefix(rest, "No Such Object"):
+			pdu.Type = "NoSuchObject"
+		case strings.HasPrefix(rest, "No Such Instance"):
+			pdu.Type = "NoSuchInstance"
+		case strings.HasPrefix(rest, "No more variables"):
+			continue
+		case rest == `""`:
+			pdu.Type = "STRING"
+		default:
+			typ, value, found := strings.Cut(rest, ": ")
+			if !found {
+				typ, value = strings.TrimSuffix(rest, ":"), ""
+			}
+
+			pdu.Type = typ
+			pdu.Value = value
+
+			// Opaque floats are printed as "Opaque: Float: 21.500000"
+			if typ == "Opaque" {
+				if inner, innerValue, ok := strings.Cut(value, ": "); ok && (inner == "Float" || inner == "Double") {
+					pdu.Type = inner
+					pdu.Value = innerValue
+				}
+			}
+		}
+
+		pdus = append(pdus, pdu)
+	}
+
+	for i := range pdus {
+		if pdus[i].Type == "STRING" {
+			pdus[i].Value = unquote(pdus[i].Value)
+		}
+	}
+
+	if len(pdus) == 0 {
+		return nil, errors.New("no PDUs found")
+	}
+
+	return pdus, nil
+}
+
+func normalizeOID(oid string) string {
+	oid = strings.TrimSpace(oid)
+	if !strings.HasPrefix(oid, ".") {
+		oid = "." + oid
+	}
+
+	return oid
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+	}
+
+	return value
+}
+
+func hexString(data []byte) string {
+	parts := make([]string, len(data))
+	for i, b := range data {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,222 @@
+package capture
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+func TestParseWalk(t *testing.T) {
+	walk := `.1.3.6.1.2.1.1.1.0 = STRING: "sensorProbe8
+second line"
+.1.3.6.1.2.1.1.2.0 = OID: .1.3.6.1.4.1.3854.1
+.1.3.6.1.4.1.3854.1.2.2.1.16.1.3.0 = INTEGER: -22
+.1.3.6.1.4.1.3854.1.2.2.1.16.1.4.0 = INTEGER: normal(2)
+.1.3.6.1.4.1.3854.3.5.1.1.99.1.1.1.1 = Opaque: Float: 27.100000
+.1.3.6.1.4.1.3854.3.5.1.1.99.1.2.1.1 = Opaque: 00 00 C8 41
+.1.3.6.1.4.1.3854.3.5.1.1.5.1.4.1.1 = ""
+.1.3.6.1.2.1.1.3.0 = Timeticks: (12345) 0:02:03.45
+`
+
+	pdus, err := ParseWalk(walk)
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	replay, err := NewReplay(pdus)
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	testcases := map[string]struct {
+		oid          string
+		expectedType gosnmp.Asn1BER
+		expected     interface{}
+	}{
+		"multiLineString": {
+			oid:          ".1.3.6.1.2.1.1.1.0",
+			expectedType: gosnmp.OctetString,
+			expected:     "sensorProbe8\nsecond line",
+		},
+		"oid": {
+			oid:          ".1.3.6.1.2.1.1.2.0",
+			expectedType: gosnmp.ObjectIdentifier,
+			expected:     ".1.3.6.1.4.1.3854.1",
+		},
+		"negativeInteger": {
+			oid:          ".1.3.6.1.4.1.3854.1.2.2.1.16.1.3.0",
+			expectedType: gosnmp.Integer,
+			expected:     -22,
+		},
+		"enum": {
+			oid:          ".1.3.6.1.4.1.3854.1.2.2.1.16.1.4.0",
+			expectedType: gosnmp.Integer,
+			expected:     2,
+		},
+		"opaqueFloat": {
+			oid:          ".1.3.6.1.4.1.3854.3.5.1.1.99.1.1.1.1",
+			expectedType: gosnmp.OpaqueFloat,
+			expected:     float32(27.1),
+		},
+		"opaque": {
+			oid:          ".1.3.6.1.4.1.3854.3.5.1.1.99.1.2.1.1",
+			expectedType: gosnmp.Opaque,
+			expected:     "\x00\x00\xc8\x41",
+		},
+		"emptyString": {
+			oid:          ".1.3.6.1.4.1.3854.3.5.1.1.5.1.4.1.1",
+			expectedType: gosnmp.OctetString,
+			expected:     "",
+		},
+		"timeticks": {
+			oid:          ".1.3.6.1.2.1.1.3.0",
+			expectedType: gosnmp.TimeTicks,
+			expected:     uint32(12345),
+		},
+		"missing": {
+			oid:          ".1.3.6.1.2.1.1.4.0",
+			expectedType: gosnmp.NoSuchObject,
+			expected:     nil,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			packet, err := replay.Get([]string{tc.oid})
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+
+			pdu := packet.Variables[0]
+
+			if pdu.Type != tc.expectedType {
+				t.Error("\nActual: ", pdu.Type, "\nExpected: ", tc.expectedType)
+			}
+
+			actual := pdu.Value
+			if tmp, ok := actual.([]byte); ok {
+				actual = string(tmp)
+			}
+
+			if actual != tc.expected {
+				t.Error("\nActual: ", actual, "\nExpected: ", tc.expected)
+			}
+		})
+	}
+}
+
+func TestReplayBulkWalkAll(t *testing.T) {
+	replay, err := NewReplay([]PDU{
+		{OID: ".1.3.6.1.4.1.3854.3.5.1.1.1.1.10.1.1", Type: "STRING", Value: "1.10.1.1"},
+		{OID: ".1.3.6.1.4.1.3854.3.5.1.1.1.1.2.1.1", Type: "STRING", Value: "1.2.1.1"},
+		{OID: ".1.3.6.1.4.1.3854.3.5.1.1.2.1.2.1.1", Type: "STRING", Value: "Humidity"},
+		{OID: "1.3.6.1.4.1.3854.3.5.1.1.1.1.1.1.1", Type: "STRING", Value: "1.1.1.1"},
+	})
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	pdus, err := replay.BulkWalkAll(".1.3.6.1.4.1.3854.3.5.1.1.1")
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	expected := []string{"1.1.1.1", "1.2.1.1", "1.10.1.1"}
+
+	if len(pdus) != len(expected) {
+		t.Fatal("\nActual: ", pdus, "\nExpected: ", expected)
+	}
+
+	for i, pdu := range pdus {
+		if string(pdu.Value.([]byte)) != expected[i] {
+			t.Error("\nActual: ", string(pdu.Value.([]byte)), "\nExpected: ", expected[i])
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	replay, err := NewReplay([]PDU{
+		{OID: ".1.3.6.1.2.1.1.5.0", Type: "STRING", Value: "sensorProbe8"},
+		{OID: ".1.3.6.1.4.1.3854.1.2.2.1.16.1.3.0", Type: "INTEGER", Value: "-22"},
+		{OID: ".1.3.6.1.4.1.3854.1.2.2.1.16.1.3.1", Type: "STRING", Value: "public"},
+		{OID: ".1.3.6.1.4.1.3854.1.2.2.1.16.1.3.2", Type: "STRING", Value: "public@10.0.0.1"},
+		{OID: ".1.3.6.1.4.1.3854.1.2.2.1.16.1.3.3", Type: "Hex-STRING", Value: "FF 70 75 62 6C 69 63 00"},
+		{OID: ".1.3.6.1.4.1.3854.1.2.2.1.16.1.3.4", Type: "STRING", Value: "key 70 75 62 6C 69 63"},
+	})
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	recorder := NewRecorder(replay)
+
+	_, err = recorder.Get([]string{".1.3.6.1.2.1.1.5.0"})
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	_, err = recorder.BulkWalkAll(".1.3.6.1.4.1.3854")
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "capture.json")
+
+	err = recorder.WriteFile(path, File{Mode: "queryAllSensors"}, []string{"public", ""})
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	recorded, err := LoadFile(path)
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	testcases := map[string]struct {
+		oid      string
+		expected interface{}
+	}{
+		"identity": {
+			oid:      ".1.3.6.1.2.1.1.5.0",
+			expected: "sensorProbe8",
+		},
+		"walk": {
+			oid:      ".1.3.6.1.4.1.3854.1.2.2.1.16.1.3.0",
+			expected: -22,
+		},
+		"redacted": {
+			oid:      ".1.3.6.1.4.1.3854.1.2.2.1.16.1.3.1",
+			expected: Redacted,
+		},
+		"redactedWithin": {
+			oid:      ".1.3.6.1.4.1.3854.1.2.2.1.16.1.3.2",
+			expected: Redacted + "@10.0.0.1",
+		},
+		"redactedBinary": {
+			oid:      ".1.3.6.1.4.1.3854.1.2.2.1.16.1.3.3",
+			expected: "\xff" + Redacted + "\x00",
+		},
+		"redactedHex": {
+			oid:      ".1.3.6.1.4.1.3854.1.2.2.1.16.1.3.4",
+			expected: "key " + Redacted,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			packet, err := recorded.Get([]string{tc.oid})
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+
+			actual := packet.Variables[0].Value
+			if b, ok := actual.([]byte); ok {
+				actual = string(b)
+			}
+
+			if actual != tc.expected {
+				t.Error("\nActual: ", actual, "\nExpected: ", tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/utils"
+	"github.com/gosnmp/gosnmp"
+)
+
+// The value which replaces secrets in a capture
+const Redacted = "REDACTED"
+
+type client interface {
+	Get(oids []string) (*gosnmp.SnmpPacket, error)
+	BulkWalkAll(rootOid string) ([]gosnmp.SnmpPDU, error)
+}
+
+// Recorder passes the requests through to a device and keeps every PDU it received
+type Recorder struct {
+	client client
+	mutex  sync.Mutex
+	pdus   map[string]gosnmp.SnmpPDU
+}
+
+func NewRecorder(c client) *Recorder {
+	return &Recorder{
+		client: c,
+		pdus:   make(map[string]gosnmp.SnmpPDU),
+	}
+}
+
+func (r *Recorder) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	packet, err := r.client.Get(oids)
+	if err != nil {
+		return packet, err
+	}
+
+	r.record(packet.Variables)
+
+	return packet, nil
+}
+
+func (r *Recorder) BulkWalkAll(rootOid string) ([]gosnmp.SnmpPDU, error) {
+	pdus, err := r.client.BulkWalkAll(rootOid)
+	if err != nil {
+		return pdus, err
+	}
+
+	r.record(pdus)
+
+	return pdus, nil
+}
+
+// The recorder may be used concurrently, as far as the recorded client allows it
+func (r *Recorder) Concurrency() int {
+	tmp, ok := r.client.(interface{ Concurrency() int })
+	if !ok {
+		return 1
+	}
+
+	return tmp.Concurrency()
+}
+
+func (r *Recorder) record(pdus []gosnmp.SnmpPDU) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, pdu := range pdus {
+		r.pdus[normalizeOID(pdu.Name)] = pdu
+	}
+}
+
+// Writes all recorded PDUs as JSON capture, the secrets (e.g. the community, which some devices
+// expose in their configuration or in trap destinations like "public@10.0.0.1") are redacted
+// wherever they occur in a value
+func (r *Recorder) WriteFile(path string, file File, secrets []string) error {
+	r.mutex.Lock()
+
+	pdus := make([]gosnmp.SnmpPDU, 0, len(r.pdus))
+	for _, pdu := range r.pdus {
+		pdus = append(pdus, pdu)
+	}
+
+	r.mutex.Unlock()
+
+	sort.Slice(pdus, func(i, j int) bool {
+		return utils.CompareOIDs(pdus[i].Name, pdus[j].Name) < 0
+	})
+
+	file.Version = FileVersion
+	file.PDUs = make([]PDU, 0, len(pdus))
+
+	for _, pdu := range pdus {
+		tmp, err := FromSnmpPDU(redact(pdu, secrets))
+		if err != nil {
+			// Types which can not be replayed are of no use for the plugin
+			continue
+		}
+
+		file.PDUs = append(file.PDUs, tmp)
+	}
+
+	content, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(content, '\n'), 0o600)
+}
+
+// Replaces the secrets within the bytes of a value, whether it is written as STRING or
+// Hex-STRING, and their hex form as some devices give binary values as text
+func redact(pdu gosnmp.SnmpPDU, secrets []string) gosnmp.SnmpPDU {
+	if pdu.Type != gosnmp.OctetString && pdu.Type != gosnmp.Opaque {
+		return pdu
+	}
+
+	value, ok := pdu.Value.([]byte)
+	if !ok {
+		return pdu
+	}
+
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+
+		value = bytes.ReplaceAll(value, []byte(secret), []byte(Redacted))
+		value = bytes.ReplaceAll(value, []byte(hexString([]byte(secret))), []byte(Redacted))
+	}
+
+	pdu.Value = value
+
+	return pdu
+}
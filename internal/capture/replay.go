@@ -0,0 +1,118 @@
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/utils"
+	"github.com/gosnmp/gosnmp"
+)
+
+// Replay answers the SNMP requests of the plugin from a capture instead of a device
+type Replay struct {
+	pdus  []gosnmp.SnmpPDU // ordered by OID
+	index map[string]int
+}
+
+// Loads a capture, either in the JSON format or as output of "snmpwalk -On"
+func LoadFile(path string) (*Replay, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var pdus []PDU
+
+	text := strings.TrimSpace(string(content))
+
+	switch {
+	case strings.HasPrefix(text, "{"):
+		var file File
+
+		err = json.Unmarshal(content, &file)
+		pdus = file.PDUs
+	case strings.HasPrefix(text, "["):
+		err = json.Unmarshal(content, &pdus)
+	default:
+		pdus, err = ParseWalk(string(content))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read capture %s: %w", path, err)
+	}
+
+	return NewReplay(pdus)
+}
+
+func NewReplay(pdus []PDU) (*Replay, error) {
+	replay := &Replay{
+		pdus:  make([]gosnmp.SnmpPDU, 0, len(pdus)),
+		index: make(map[string]int, len(pdus)),
+	}
+
+	for _, pdu := range pdus {
+		tmp, err := pdu.ToSnmpPDU()
+		if err != nil {
+			return nil, err
+		}
+
+		replay.pdus = append(replay.pdus, tmp)
+	}
+
+	sort.SliceStable(replay.pdus, func(i, j int) bool {
+		return utils.CompareOIDs(replay.pdus[i].Name, replay.pdus[j].Name) < 0
+	})
+
+	for i, pdu := range replay.pdus {
+		replay.index[pdu.Name] = i
+	}
+
+	return replay, nil
+}
+
+// Returns the captured values, OIDs which are not part of the capture are answered
+// with noSuchObject like a SNMPv2c agent would do
+func (r *Replay) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	packet := &gosnmp.SnmpPacket{
+		Variables: make([]gosnmp.SnmpPDU, len(oids)),
+	}
+
+	for i, oid := range oids {
+		oid = normalizeOID(oid)
+
+		idx, ok := r.index[oid]
+		if !ok {
+			packet.Variables[i] = gosnmp.SnmpPDU{Name: oid, Type: gosnmp.NoSuchObject}
+			continue
+		}
+
+		packet.Variables[i] = r.pdus[idx]
+	}
+
+	return packet, nil
+}
+
+// Returns all captured values below the given OID
+func (r *Replay) BulkWalkAll(rootOid string) ([]gosnmp.SnmpPDU, error) {
+	rootOid = normalizeOID(rootOid)
+
+	var results []gosnmp.SnmpPDU
+
+	for _, pdu := range r.pdus {
+		if strings.HasPrefix(pdu.Name, rootOid+".") {
+			results = append(results, pdu)
+		}
+	}
+
+	// Like gosnmp, a walk on a scalar returns the scalar itself
+	if len(results) == 0 {
+		if idx, ok := r.index[rootOid]; ok {
+			results = append(results, r.pdus[idx])
+		}
+	}
+
+	return results, nil
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/gosnmp/gosnmp"
@@ -12,9 +14,9 @@ type Cell struct {
 }
 
 // Get a list of PDUs and try to form a table from it
-// prefixLength parts of the oid are ignored from the beginning of the oid.
-// The suffix determines the id of entries from the end of the oid
-// its length is computed by (number of oid points - 1 - prefixLength)
+// prefixLength parts of the oid are ignored from the beginning of the oid,
+// the next part is the id of the column.
+// The suffix (everything after the column id) determines the id of the row
 //
 // Assumptions:
 // All OIDs in the list _table_ have the same length (= number of separators)
@@ -25,7 +27,7 @@ func ParseSnmpTable(table *[]gosnmp.SnmpPDU, prefixLength uint) (*map[string][]C
 		oid := value.Name
 		tmp := strings.Split(oid, ".")
 		id := tmp[prefixLength]
-		rowID := strings.Join(tmp[prefixLength+1:len(tmp)-1], ".")
+		rowID := strings.Join(tmp[prefixLength+1:], ".")
 
 		entry, ok := result[rowID]
 		cellVal := Cell{
@@ -43,3 +45,46 @@ func ParseSnmpTable(table *[]gosnmp.SnmpPDU, prefixLength uint) (*map[string][]C
 
 	return &result, nil
 }
+
+// Returns the row ids of a table in the order of the OIDs (e.g. "1.2" before "1.10")
+func SortedRowIDs(table map[string][]Cell) []string {
+	ids := make([]string, 0, len(table))
+	for id := range table {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return CompareOIDs(ids[i], ids[j]) < 0
+	})
+
+	return ids
+}
+
+// Compares two OIDs (or parts of them) numerically, part by part
+func CompareOIDs(a, b string) int {
+	partsA := strings.Split(strings.TrimPrefix(a, "."), ".")
+	partsB := strings.Split(strings.TrimPrefix(b, "."), ".")
+
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		numA, errA := strconv.ParseUint(partsA[i], 10, 64)
+		numB, errB := strconv.ParseUint(partsB[i], 10, 64)
+
+		if errA != nil || errB != nil {
+			if c := strings.Compare(partsA[i], partsB[i]); c != 0 {
+				return c
+			}
+
+			continue
+		}
+
+		if numA < numB {
+			return -1
+		}
+
+		if numA > numB {
+			return 1
+		}
+	}
+
+	return len(partsA) - len(partsB)
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+func TestParseSnmpTable(t *testing.T) {
+	table := []gosnmp.SnmpPDU{
+		{Name: ".1.3.6.1.4.1.3854.3.5.2.1.2.1.1.1.1", Type: gosnmp.OctetString, Value: []byte("Temperature Port 1")},
+		{Name: ".1.3.6.1.4.1.3854.3.5.2.1.2.1.1.1.2", Type: gosnmp.OctetString, Value: []byte("Temperature Port 1b")},
+		{Name: ".1.3.6.1.4.1.3854.3.5.2.1.2.1.10.1.1", Type: gosnmp.OctetString, Value: []byte("Temperature Port 10")},
+		{Name: ".1.3.6.1.4.1.3854.3.5.2.1.4.1.1.1.1", Type: gosnmp.Integer, Value: 21},
+		{Name: ".1.3.6.1.4.1.3854.3.5.2.1.4.1.1.1.2", Type: gosnmp.Integer, Value: 22},
+		{Name: ".1.3.6.1.4.1.3854.3.5.2.1.4.1.10.1.1", Type: gosnmp.Integer, Value: 23},
+	}
+
+	rows, err := ParseSnmpTable(&table, 12)
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	actual := SortedRowIDs(*rows)
+	expected := []string{"1.1.1.1", "1.1.1.2", "1.10.1.1"}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Error("\nActual: ", actual, "\nExpected: ", expected)
+	}
+
+	for _, id := range expected {
+		if len((*rows)[id]) != 2 {
+			t.Error("Expected 2 cells in row ", id, ", got ", len((*rows)[id]))
+		}
+
+		if (*rows)[id][0].ID != "2" || (*rows)[id][1].ID != "4" {
+			t.Error("Unexpected column IDs in row ", id)
+		}
+	}
+}
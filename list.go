@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+	"github.com/NETWAYS/go-check"
+)
+
+// A single line of the output of the listPossibleSensors mode
+type sensorListEntry struct {
+	Index    string  `json:"index"`
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Unit     string  `json:"unit"`
+	Value    float64 `json:"value"`
+	Warning  string  `json:"warning,omitempty"`
+	Critical string  `json:"critical,omitempty"`
+}
+
+func listPossibleSensorsMode(params akcp.Client, c *Config, deviceType int) error {
+	sensors, err := akcp.QueryAllSensorDetails(params, deviceType)
+	if err != nil {
+		return err
+	}
+
+	err = akcp.AddTypeTableDetails(params, sensors, deviceType)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]sensorListEntry, 0, len(sensors))
+
+	for _, details := range sensors {
+		entries = append(entries, newSensorListEntry(details.Index, details, deviceType))
+	}
+
+	return printSensorList(os.Stdout, entries, c.outputFormat)
+}
+
+func newSensorListEntry(index string, details akcp.SensorDetails, deviceType int) sensorListEntry {
+	entry := sensorListEntry{
+		Index: index,
+		Name:  details.Name,
+		Type:  akcp.GetSensorTypeName(details.SensorType, deviceType),
+		Unit:  details.Unit,
+		Value: details.Value,
+	}
+
+	if details.Warning.Present {
+		entry.Warning = details.Warning.Val.String()
+	}
+
+	if details.Critical.Present {
+		entry.Critical = details.Critical.Val.String()
+	}
+
+	return entry
+}
+
+func printSensorList(w io.Writer, entries []sensorListEntry, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(entries)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "INDEX\tNAME\tTYPE\tUNIT\tVALUE\tWARNING\tCRITICAL")
+
+	for _, entry := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			entry.Index, entry.Name, entry.Type, entry.Unit, check.FormatFloat(entry.Value), entry.Warning, entry.Critical)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+	"github.com/NETWAYS/go-check"
+)
+
+func TestPrintSensorList(t *testing.T) {
+	details := akcp.SensorDetails{
+		SensorType: sensorProbePlus.Temperature,
+		Name:       "Temperature Port 1",
+		Value:      21.5,
+		Unit:       "C",
+		Warning: akcp.MayThreshold{
+			Present: true,
+			Val:     check.Threshold{Lower: 18, Upper: 27},
+		},
+	}
+
+	entries := []sensorListEntry{newSensorListEntry("1.1.1.1", details, akcp.SensorProbePlusType)}
+
+	testcases := map[string]struct {
+		format   string
+		expected string
+	}{
+		"text": {
+			format:   "text",
+			expected: "1.1.1.1  Temperature Port 1  temperature  C     21.5   18:27",
+		},
+		"json": {
+			format:   "json",
+			expected: `"type": "temperature",`,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			err := printSensorList(&buf, entries, tc.format)
+			if err != nil {
+				t.Error("Expected no error, got ", err)
+			}
+
+			actual := buf.String()
+
+			if !strings.Contains(actual, tc.expected) {
+				t.Error("\nActual: ", actual, "\nExpected: ", tc.expected)
+			}
+		})
+	}
+}
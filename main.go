@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/NETWAYS/go-check"
 	"github.com/NETWAYS/go-check/result"
@@ -48,13 +49,21 @@ func main() {
 	plugin.Name = "check_akcp_sensorprobeXplus"
 	plugin.Version = version
 	plugin.Readme = readme
-	plugin.Timeout = 30
+	// The timeout handler would terminate the exporter, it is enabled after parsing for all other modes
+	plugin.DefaultHelper = false
 
 	config := &Config{}
 	config.BindArguments(plugin.FlagSet)
 
 	plugin.ParseArguments()
 
+	if config.mode != "serve" {
+		plugin.EnableTimeoutHandler()
+	}
+
+	config.verbose = plugin.Verbose
+	config.timeout = time.Duration(plugin.Timeout) * time.Second
+
 	if len(os.Args) <= 1 {
 		plugin.FlagSet.Usage()
 		check.ExitRaw(check.Unknown, "No arguments given")
@@ -63,16 +72,50 @@ func main() {
 	err := config.Validate()
 
 	if err != nil {
+		if config.outputFormat == "json" {
+			config.report.Error = err.Error()
+			exitReport(config.report, check.Unknown)
+		}
+
 		check.ExitError(err)
 	}
 
+	if config.isTestRun() {
+		check.ExitRaw(check.OK, "It seems like you can execute this programm")
+	}
+
 	var overall result.Overall
 
 	err = config.Run(&overall)
 
 	if err != nil {
-		check.ExitError(err)
+		state, explained := config.explainError(err)
+
+		if config.outputFormat == "json" {
+			config.report.Error = explained.Error()
+			exitReport(config.report, state)
+		}
+
+		check.ExitRaw(state, explained.Error())
+	}
+
+	if config.printsOwnOutput() {
+		check.BaseExit(check.OK)
+	}
+
+	if config.outputFormat == "json" {
+		exitReport(config.report, overall.GetStatus())
 	}
 
 	check.ExitRaw(overall.GetStatus(), overall.GetOutput())
 }
+
+// Prints the result as JSON for --output json, errors are part of the document as well
+func exitReport(report checkReport, state int) {
+	err := printCheckReport(os.Stdout, report, state)
+	if err != nil {
+		check.ExitError(err)
+	}
+
+	check.BaseExit(state)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+	"github.com/NETWAYS/go-check"
+)
+
+// The result of a check for --output json
+type checkReport struct {
+	Device *deviceReport `json:"device,omitempty"`
+	// Used instead of Device if several hosts are checked
+	Devices  []deviceReport `json:"devices,omitempty"`
+	Sensors  []sensorReport `json:"sensors"`
+	State    string         `json:"state"`
+	ExitCode int            `json:"exit_code"`
+	// Set if the check failed as a whole (e.g. the device did not answer)
+	Error string `json:"error,omitempty"`
+}
+
+type deviceReport struct {
+	Host     string `json:"host,omitempty"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Type     string `json:"type"`
+	Error    string `json:"error,omitempty"`
+}
+
+type sensorReport struct {
+	Device       string  `json:"device,omitempty"`
+	Index        string  `json:"index"`
+	Name         string  `json:"name"`
+	Type         string  `json:"type"`
+	Value        float64 `json:"value"`
+	Unit         string  `json:"unit"`
+	Status       string  `json:"status"`
+	Acknowledged bool    `json:"acknowledged"`
+	Warning      string  `json:"warning,omitempty"`
+	Critical     string  `json:"critical,omitempty"`
+	Contact      string  `json:"contact,omitempty"`
+	NormalState  string  `json:"normal_state,omitempty"`
+	Direction    string  `json:"direction,omitempty"`
+	Rope         string  `json:"rope,omitempty"`
+	RopeLength   float64 `json:"rope_length,omitempty"`
+	State        string  `json:"state"`
+	Error        string  `json:"error,omitempty"`
+}
+
+func newDeviceReport(identity akcp.DeviceIdentity) *deviceReport {
+	return &deviceReport{
+		Name:     identity.Name,
+		Location: identity.Location,
+		Type:     identity.Type,
+	}
+}
+
+func newSensorReport(sensor akcp.SensorDetails, deviceType int, state int) sensorReport {
+	entry := sensorReport{
+		Index:        sensor.Index,
+		Name:         sensor.Name,
+		Type:         akcp.GetSensorTypeName(sensor.SensorType, deviceType),
+		Value:        sensor.Value,
+		Unit:         sensor.Unit,
+		Status:       sensor.Status.String(),
+		Acknowledged: sensor.Acknowledged,
+		State:        check.StatusText(state),
+	}
+
+	if sensor.Warning.Present {
+		entry.Warning = sensor.Warning.Val.String()
+	}
+
+	if sensor.Critical.Present {
+		entry.Critical = sensor.Critical.Val.String()
+	}
+
+	if akcp.IsContact(sensor.SensorType) && sensor.NormalState != akcp.ContactStateUnknown {
+		entry.Contact = sensor.ContactState().String()
+		entry.NormalState = sensor.NormalState.String()
+		entry.Direction = sensor.Direction.String()
+	}
+
+	if akcp.IsWaterRope(sensor.SensorType) {
+		entry.Rope = sensor.RopeState().String()
+		entry.RopeLength = sensor.RopeLength
+	}
+
+	if sensor.Err != nil {
+		entry.Error = sensor.Err.Error()
+	}
+
+	return entry
+}
+
+func printCheckReport(w io.Writer, report checkReport, state int) error {
+	report.State = check.StatusText(state)
+	report.ExitCode = state
+
+	if report.Sensors == nil {
+		report.Sensors = []sensorReport{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+	"github.com/NETWAYS/go-check"
+)
+
+func TestPrintCheckReport(t *testing.T) {
+	details := akcp.SensorDetails{
+		Index:        "1.1.1.1",
+		SensorType:   sensorProbePlus.Temperature,
+		Name:         "Temperature Port 1",
+		Value:        28.5,
+		Unit:         "C",
+		Status:       akcp.HighWarning,
+		Acknowledged: true,
+		Warning: akcp.MayThreshold{
+			Present: true,
+			Val:     check.Threshold{Lower: 18, Upper: 27},
+		},
+	}
+
+	report := checkReport{
+		Device:  newDeviceReport(akcp.DeviceIdentity{Name: "SPX+ Demo", Location: "Room 217", Type: "SPX+"}),
+		Sensors: []sensorReport{newSensorReport(details, akcp.SensorProbePlusType, check.Warning)},
+	}
+
+	var buf bytes.Buffer
+
+	err := printCheckReport(&buf, report, check.Warning)
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	var actual checkReport
+
+	err = json.Unmarshal(buf.Bytes(), &actual)
+	if err != nil {
+		t.Fatal("Expected valid JSON, got ", err)
+	}
+
+	expected := sensorReport{
+		Index:        "1.1.1.1",
+		Name:         "Temperature Port 1",
+		Type:         "temperature",
+		Value:        28.5,
+		Unit:         "C",
+		Status:       "highWarning",
+		Acknowledged: true,
+		Warning:      "18:27",
+		State:        "WARNING",
+	}
+
+	if len(actual.Sensors) != 1 || actual.Sensors[0] != expected {
+		t.Error("\nActual: ", actual.Sensors, "\nExpected: ", expected)
+	}
+
+	if actual.Device.Name != "SPX+ Demo" || actual.State != "WARNING" || actual.ExitCode != check.Warning {
+		t.Error("\nActual: ", actual, "\nExpected device SPX+ Demo with state WARNING")
+	}
+}
+
+func TestPrintCheckReportError(t *testing.T) {
+	report := checkReport{Error: "device did not answer: request timeout"}
+
+	var buf bytes.Buffer
+
+	err := printCheckReport(&buf, report, check.Critical)
+	if err != nil {
+		t.Fatal("Expected no error, got ", err)
+	}
+
+	var actual checkReport
+
+	err = json.Unmarshal(buf.Bytes(), &actual)
+	if err != nil {
+		t.Fatal("Expected valid JSON, got ", err)
+	}
+
+	if actual.Error != report.Error || actual.State != "CRITICAL" || actual.ExitCode != check.Critical || actual.Sensors == nil {
+		t.Error("\nActual: ", actual, "\nExpected: ", report.Error, " with state CRITICAL")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+	"github.com/NETWAYS/go-check"
+)
+
+// A threshold given by the user, optionally limited to sensors selected as in sensorSelector
+//
+// Format: [<selector>=]<range>
+type thresholdRule struct {
+	sensorSelector
+	threshold check.Threshold
+}
+
+type thresholdRules []thresholdRule
+
+func parseThresholdRules(specs []string) (thresholdRules, error) {
+	rules := make(thresholdRules, 0, len(specs))
+
+	for _, spec := range specs {
+		selector, rangeSpec, err := parseSensorSelector(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold: %w", err)
+		}
+
+		threshold, err := check.ParseThreshold(rangeSpec)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, thresholdRule{sensorSelector: selector, threshold: *threshold})
+	}
+
+	return rules, nil
+}
+
+// Returns the most specific threshold for the sensor
+func (r thresholdRules) lookup(sensor akcp.SensorDetails) (check.Threshold, bool) {
+	rule, found := lookupRule(r, sensor)
+
+	return rule.threshold, found
+}
+
+// Replaces the thresholds of the device with the ones given by the user and
+// evaluates the value of the sensor against them.
+// If no threshold was given for the sensor, the status of the device is kept.
+func applyThresholds(sensor *akcp.SensorDetails, warning thresholdRules, critical thresholdRules) {
+	// Only the device knows the state of a contact or a leak, their value is no measurement
+	// (the location of the leak for a water rope)
+	if akcp.IsStateOnly(sensor.SensorType) {
+		return
+	}
+
+	warn, warnFound := warning.lookup(*sensor)
+	crit, critFound := critical.lookup(*sensor)
+
+	if !warnFound && !critFound {
+		return
+	}
+
+	if warnFound {
+		sensor.Warning = akcp.MayThreshold{Present: true, Val: warn}
+	}
+
+	if critFound {
+		sensor.Critical = akcp.MayThreshold{Present: true, Val: crit}
+	}
+
+	// The device knows better if the sensor is working at all
+	if sensor.Status == akcp.SensorError || sensor.Status == akcp.NoStatus {
+		return
+	}
+
+	sensor.Status = evaluateThresholds(sensor.Value, sensor.Warning, sensor.Critical)
+}
+
+func evaluateThresholds(value float64, warning akcp.MayThreshold, critical akcp.MayThreshold) akcp.SensorStatus {
+	if critical.Present && critical.Val.DoesViolate(value) {
+		if !critical.Val.Inside && value < critical.Val.Lower {
+			return akcp.LowCritical
+		}
+
+		return akcp.HighCritical
+	}
+
+	if warning.Present && warning.Val.DoesViolate(value) {
+		if !warning.Val.Inside && value < warning.Val.Lower {
+			return akcp.LowWarning
+		}
+
+		return akcp.HighWarning
+	}
+
+	return akcp.Normal
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp/sensorProbePlus"
+	"github.com/NETWAYS/go-check"
+)
+
+func TestApplyThresholds(t *testing.T) {
+	testcases := map[string]struct {
+		sensor   akcp.SensorDetails
+		warning  []string
+		critical []string
+		expected akcp.SensorStatus
+	}{
+		"noRuleKeepsDeviceStatus": {
+			sensor: akcp.SensorDetails{
+				SensorType: sensorProbePlus.Temperature,
+				Value:      35,
+				Status:     akcp.HighWarning,
+			},
+			expected: akcp.HighWarning,
+		},
+		"globalCritical": {
+			sensor: akcp.SensorDetails{
+				SensorType: sensorProbePlus.Temperature,
+				Value:      35,
+				Status:     akcp.Normal,
+			},
+			critical: []string{"30"},
+			expected: akcp.HighCritical,
+		},
+		"lowWarning": {
+			sensor: akcp.SensorDetails{
+				SensorType: sensorProbePlus.Temperature,
+				Value:      15,
+				Status:     akcp.Normal,
+			},
+			warning:  []string{"18:27"},
+			expected: akcp.LowWarning,
+		},
+		"typeBeatsGlobal": {
+			sensor: akcp.SensorDetails{
+				SensorType: sensorProbePlus.Humidity_dual,
+				Value:      50,
+				Status:     akcp.Normal,
+			},
+			warning:  []string{"18:27", "humidity_dual=30:60"},
+			expected: akcp.Normal,
+		},
+		"nameBeatsType": {
+			sensor: akcp.SensorDetails{
+				SensorType: sensorProbePlus.Temperature,
+				Name:       "Rack 3 Inlet",
+				Value:      29,
+				Status:     akcp.Normal,
+			},
+			warning:  []string{"/^Rack [0-9]+/=15:28", "temperature=15:30"},
+			expected: akcp.HighWarning,
+		},
+		"portBeatsType": {
+			sensor: akcp.SensorDetails{
+				SensorType: sensorProbePlus.Temperature,
+				Index:      "1.3.1.1",
+				Value:      29,
+				Status:     akcp.Normal,
+			},
+			warning:  []string{"3=15:28", "temperature=15:30"},
+			expected: akcp.HighWarning,
+		},
+		"deviceThresholdKept": {
+			sensor: akcp.SensorDetails{
+				SensorType: sensorProbePlus.Temperature,
+				Value:      45,
+				Status:     akcp.HighCritical,
+				Critical: akcp.MayThreshold{
+					Present: true,
+					Val:     check.Threshold{Lower: 10, Upper: 40},
+				},
+			},
+			warning:  []string{"18:27"},
+			expected: akcp.HighCritical,
+		},
+		"contactIgnored": {
+			sensor: akcp.SensorDetails{
+				SensorType:  sensorProbePlus.Dry_inout,
+				Value:       1,
+				Status:      akcp.HighCritical,
+				NormalState: akcp.ContactOpen,
+			},
+			warning:  []string{"18:27"},
+			expected: akcp.HighCritical,
+		},
+		"motionIgnored": {
+			sensor: akcp.SensorDetails{
+				SensorType: sensorProbePlus.Motion,
+				Status:     akcp.Normal,
+			},
+			critical: []string{"18:27"},
+			expected: akcp.Normal,
+		},
+		"sensorErrorKept": {
+			sensor: akcp.SensorDetails{
+				SensorType: sensorProbePlus.Temperature,
+				Value:      20,
+				Status:     akcp.SensorError,
+			},
+			warning:  []string{"18:27"},
+			expected: akcp.SensorError,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			warning, err := parseThresholdRules(tc.warning)
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+
+			critical, err := parseThresholdRules(tc.critical)
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+
+			warning, err = resolveSensorTypes(warning, akcp.SensorProbePlusType)
+			if err != nil {
+				t.Fatal("Expected no error, got ", err)
+			}
+
+			sensor := tc.sensor
+			applyThresholds(&sensor, warning, critical)
+
+			if sensor.Status != tc.expected {
+				t.Error("\nActual: ", sensor.Status, "\nExpected: ", tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/NETWAYS/check_akcp_sensorprobeXplus/internal/akcp"
+	"github.com/NETWAYS/go-check"
+	"github.com/NETWAYS/go-check/perfdata"
+	"github.com/NETWAYS/go-check/result"
+)
+
+// A leak is CRITICAL, its distance from the start of the rope is given in the output and
+// the performance data. A broken rope can not detect leaks, so it is UNKNOWN.
+// The performance data is given for every state, with a distance of 0 if there is no known leak.
+func mapWaterRopeStatus(sensor akcp.SensorDetails, overall *result.Overall) {
+	sc := result.PartialResult{}
+	_ = sc.SetDefaultState(check.Unknown)
+
+	distance, known := sensor.LeakDistance()
+
+	switch state := sensor.RopeState(); state {
+	case akcp.RopeDry:
+		_ = sc.SetState(check.OK)
+		sc.Output = fmt.Sprintf("%s: %s", sensor.Name, state)
+	case akcp.RopeLeak:
+		_ = sc.SetState(check.Critical)
+
+		if !known {
+			sc.Output = fmt.Sprintf("%s: leak at an unknown location", sensor.Name)
+
+			break
+		}
+
+		sc.Output = fmt.Sprintf("%s: leak at %.1f%s", sensor.Name, distance, sensor.Unit)
+
+		if sensor.RopeLength > 0 {
+			sc.Output += fmt.Sprintf(" of %.1f%s", sensor.RopeLength, sensor.Unit)
+		}
+	case akcp.RopeBroken:
+		_ = sc.SetState(check.Unknown)
+		sc.Output = fmt.Sprintf("%s: %s, leaks can not be detected", sensor.Name, state)
+	default:
+		_ = sc.SetState(check.Unknown)
+		sc.Output = sensor.Name + " is unknown (No Status)!"
+	}
+
+	pf := perfdata.Perfdata{
+		Label: sensor.Name,
+		Value: distance,
+		Uom:   perfdataUnit(sensor.Unit),
+		Min:   0.0,
+	}
+
+	if sensor.RopeLength > 0 {
+		pf.Max = sensor.RopeLength
+	}
+
+	sc.Perfdata.Add(&pf)
+
+	overall.AddSubcheck(sc)
+}